@@ -0,0 +1,105 @@
+// Package catalog describes the blocks of stdlib, communications and grove
+// for front ends: as the nio block type catalog, as JSON Schema for the
+// config of each block, and as a validator of block configs.
+package catalog
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/niolabs/gonio-blocks/communications"
+	"github.com/niolabs/gonio-blocks/grove"
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+)
+
+// Entries returns every block type entry with a definition, keyed by block
+// type name. The communications blocks are created without a connection and
+// the grove blocks on the default bus, so the entries are only fit for
+// describing the blocks.
+func Entries() map[string]nio.BlockTypeEntry {
+	entries := map[string]nio.BlockTypeEntry{}
+
+	for _, entry := range []nio.BlockTypeEntry{
+		stdlib.CounterIntervalSimulator,
+		stdlib.IdentityIntervalSimulator,
+		stdlib.RandomIntervalSimulator,
+		stdlib.WaveformIntervalSimulator,
+		stdlib.ReplaySimulator,
+		stdlib.Logger,
+		stdlib.Modifier,
+		stdlib.Filter,
+		stdlib.AttributeSelector,
+		stdlib.Noop,
+		stdlib.Counter,
+		stdlib.Debounce,
+		stdlib.Switch,
+		stdlib.AppendState,
+		stdlib.MergeStreams,
+		stdlib.Delay,
+		stdlib.RateLimit,
+		stdlib.Watchdog,
+		stdlib.Alarm,
+		stdlib.OnChange,
+		stdlib.Explode,
+		stdlib.Collect,
+		stdlib.Dedupe,
+		stdlib.Sampler,
+		communications.NewPublisher(nil),
+		communications.NewSubscriber(nil),
+		grove.DefaultADXL345,
+	} {
+		entries[entry.Definition.Name] = entry
+	}
+
+	return entries
+}
+
+// Catalog returns the definitions of entries keyed by block type name, in
+// the shape of the catalog a nio instance serves.
+func Catalog(entries map[string]nio.BlockTypeEntry) map[string]nio.BlockTypeDefinition {
+	definitions := make(map[string]nio.BlockTypeDefinition, len(entries))
+	for name, entry := range entries {
+		definitions[name] = entry.Definition
+	}
+	return definitions
+}
+
+// Schemas returns the JSON Schema of the config of every entry, keyed by
+// block type name.
+func Schemas(entries map[string]nio.BlockTypeEntry) (map[string]map[string]interface{}, error) {
+	schemas := make(map[string]map[string]interface{}, len(entries))
+	for name, entry := range entries {
+		schema, err := Schema(entry.Definition)
+		if err != nil {
+			return nil, err
+		}
+		schemas[name] = schema
+	}
+	return schemas, nil
+}
+
+// Names returns the block type names of entries in order.
+func Names(entries map[string]nio.BlockTypeEntry) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalize round-trips v through JSON, so definitions built in Go, with
+// their typed maps and slices, can be walked as plain JSON values.
+func normalize(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
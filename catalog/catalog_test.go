@@ -0,0 +1,44 @@
+package catalog_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/niolabs/gonio-blocks/catalog"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntries(t *testing.T) {
+	assert := assert.New(t)
+
+	entries := catalog.Entries()
+
+	for _, name := range []string{"CounterIntervalSimulator", "Modifier", "Filter", "AttributeSelector", "Logger", "Publisher", "Subscriber", "DeviceAccelerometer"} {
+		assert.Contains(entries, name)
+	}
+
+	for name, entry := range entries {
+		assert.Equal(name, entry.Definition.Name)
+		assert.NotEmpty(entry.Definition.Version, "%s has no version", name)
+		assert.NotEmpty(entry.Definition.Namespace, "%s has no namespace", name)
+		assert.Contains(entry.Definition.Properties, nio.Property("type"), "%s lacks the common properties", name)
+		assert.NotNil(entry.Create(), "%s creates no block", name)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	assert := assert.New(t)
+
+	entries := catalog.Entries()
+	definitions := catalog.Catalog(entries)
+
+	assert.Len(definitions, len(entries))
+	assert.Equal(entries["Modifier"].Definition, definitions["Modifier"])
+}
+
+func TestNames(t *testing.T) {
+	names := catalog.Names(catalog.Entries())
+
+	assert.True(t, sort.StringsAreSorted(names), "names are not sorted: %v", names)
+}
@@ -0,0 +1,96 @@
+// Command nio-catalog writes the catalog of the gonio blocks, or the JSON
+// Schema of their configs, to standard output, or validates a block config.
+//
+//	nio-catalog                      the block type catalog
+//	nio-catalog -schema              the schema of every block type
+//	nio-catalog -validate config.json [-type Modifier]
+//
+// A config is validated against the block type it names, unless -type is
+// given. Each property error is printed on a line of its own, and the exit
+// status is 1 if there are any.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/niolabs/gonio-blocks/catalog"
+	"github.com/niolabs/gonio-framework"
+)
+
+func main() {
+	schema := flag.Bool("schema", false, "write the JSON Schema of every block type instead of the catalog")
+	validate := flag.String("validate", "", "validate the block config in `file`")
+	blockType := flag.String("type", "", "the block `type` to validate against; defaults to the type in the config")
+	flag.Parse()
+
+	entries := catalog.Entries()
+
+	var err error
+	switch {
+	case *validate != "":
+		var ok bool
+		ok, err = validateConfig(entries, *validate, *blockType)
+		if err == nil && !ok {
+			os.Exit(1)
+		}
+	case *schema:
+		var schemas map[string]map[string]interface{}
+		if schemas, err = catalog.Schemas(entries); err == nil {
+			err = write(schemas)
+		}
+	default:
+		err = write(catalog.Catalog(entries))
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nio-catalog:", err)
+		os.Exit(2)
+	}
+}
+
+func write(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// validateConfig prints the property errors of the config at path, and
+// reports whether there were none.
+func validateConfig(entries map[string]nio.BlockTypeEntry, path, blockType string) (bool, error) {
+	config, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	if blockType == "" {
+		var atom nio.BlockConfigAtom
+		if err := json.Unmarshal(config, &atom); err != nil {
+			return false, err
+		}
+		blockType = atom.Type
+	}
+
+	entry, ok := entries[blockType]
+	if !ok {
+		return false, fmt.Errorf("unknown block type `%s'", blockType)
+	}
+
+	schema, err := catalog.Schema(entry.Definition)
+	if err != nil {
+		return false, err
+	}
+
+	errs, err := catalog.Validate(schema, config)
+	if err != nil {
+		return false, err
+	}
+
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+	return len(errs) == 0, nil
+}
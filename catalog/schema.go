@@ -0,0 +1,217 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/niolabs/gonio-framework"
+)
+
+// SchemaVersion is the JSON Schema draft the schemas are written against.
+const SchemaVersion = "http://json-schema.org/draft-07/schema#"
+
+// expressionSchema matches a property set to an expression, which is
+// evaluated against each signal and so can't be checked ahead of time.
+var expressionSchema = map[string]interface{}{
+	"type":    "string",
+	"pattern": `\{\{`,
+}
+
+// timeDeltaUnits are the keys of a TimeDeltaType value.
+var timeDeltaUnits = []string{"days", "hours", "minutes", "seconds", "milliseconds", "microseconds"}
+
+// Schema returns the JSON Schema of the config of a block type. Every
+// property is described by the schema of its designer type, and properties
+// that may not be unset and have no default, in themselves or in the
+// template of their object, are required. Properties of types that may hold
+// expressions accept any expression as well.
+//
+// The terminals, commands and designer attributes that have no place in JSON
+// Schema are kept under x-nio keys, so a front end can render a block from
+// its schema alone.
+func Schema(definition nio.BlockTypeDefinition) (map[string]interface{}, error) {
+	properties, err := normalize(definition.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", definition.Name, err)
+	}
+
+	schema := objectSchema(asObject(properties))
+	schema["$schema"] = SchemaVersion
+	schema["title"] = definition.Name
+	schema["x-nio-namespace"] = definition.Namespace
+	schema["x-nio-version"] = definition.Version
+
+	inputs, err := normalize(definition.BlockAttributes.Inputs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", definition.Name, err)
+	}
+	outputs, err := normalize(definition.BlockAttributes.Outputs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", definition.Name, err)
+	}
+	schema["x-nio-inputs"] = orEmpty(inputs)
+	schema["x-nio-outputs"] = orEmpty(outputs)
+
+	commands, err := normalize(definition.Commands)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", definition.Name, err)
+	}
+	commandSchemas := map[string]interface{}{}
+	for name, command := range asObject(commands) {
+		command := asObject(command)
+		params := objectSchema(asObject(command["params"]))
+		params["title"] = command["title"]
+		commandSchemas[name] = params
+	}
+	schema["x-nio-commands"] = commandSchemas
+
+	return schema, nil
+}
+
+// objectSchema describes an object with the given property definitions.
+func objectSchema(definitions map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for name, definition := range definitions {
+		definition := asObject(definition)
+		properties[name] = propertySchema(definition)
+
+		if allowNone, _ := definition["allow_none"].(bool); !allowNone && definition["default"] == nil && !defaulted(definition) {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// defaulted reports whether an object property without a default of its
+// own takes every property from the defaults of its template.
+func defaulted(definition map[string]interface{}) bool {
+	template, ok := definition["template"].(map[string]interface{})
+	if !ok || definition["type"] != "ObjectType" {
+		return false
+	}
+
+	_, required := objectSchema(template)["required"]
+	return !required
+}
+
+// propertySchema describes a single property definition.
+func propertySchema(definition map[string]interface{}) map[string]interface{} {
+	schema := valueSchema(definition)
+
+	if allowNone, _ := definition["allow_none"].(bool); allowNone {
+		schema = anyOf(schema, map[string]interface{}{"type": "null"})
+	}
+
+	if title, ok := definition["title"]; ok {
+		schema["title"] = title
+	}
+	if value := definition["default"]; value != nil {
+		schema["default"] = value
+	}
+	for _, key := range []string{"type", "order", "advanced", "visible", "readonly", "obj_type"} {
+		if value, ok := definition[key]; ok && value != nil {
+			schema["x-nio-"+key] = value
+		}
+	}
+
+	return schema
+}
+
+// valueSchema describes the values of a property definition's type.
+func valueSchema(definition map[string]interface{}) map[string]interface{} {
+	propertyType, _ := definition["type"].(string)
+
+	switch propertyType {
+	case "StringType":
+		return map[string]interface{}{"type": "string"}
+	case "IntType":
+		return anyOf(map[string]interface{}{"type": "integer"}, expressionSchema)
+	case "FloatType":
+		return anyOf(map[string]interface{}{"type": "number"}, expressionSchema)
+	case "BoolType":
+		return anyOf(map[string]interface{}{"type": "boolean"}, expressionSchema)
+	case "TimeDeltaType":
+		units := map[string]interface{}{}
+		for _, unit := range timeDeltaUnits {
+			units[unit] = map[string]interface{}{"type": "number"}
+		}
+		return anyOf(map[string]interface{}{
+			"type":                 "object",
+			"properties":           units,
+			"additionalProperties": false,
+		}, expressionSchema)
+	case "SelectType":
+		return anyOf(map[string]interface{}{"enum": optionValues(definition["options"])}, expressionSchema)
+	case "ObjectType":
+		if template, ok := definition["template"].(map[string]interface{}); ok {
+			return objectSchema(template)
+		}
+		return map[string]interface{}{"type": "object"}
+	case "ListType":
+		items := map[string]interface{}{}
+		if template, ok := definition["template"].(map[string]interface{}); ok {
+			items = objectSchema(template)
+		} else if objType, ok := definition["list_obj_type"].(string); ok {
+			items = valueSchema(map[string]interface{}{"type": objType})
+		}
+		return map[string]interface{}{"type": "array", "items": items}
+	default:
+		// Type, and any type this package doesn't know, holds any value
+		return map[string]interface{}{}
+	}
+}
+
+// optionValues returns the values a select accepts. Selects of enums, such
+// as log_level, map their names to numbers and are configured by name; all
+// other selects map labels to the values they are configured with.
+func optionValues(options interface{}) []interface{} {
+	labels := []string{}
+	for label := range asObject(options) {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	values := []interface{}{}
+	for _, label := range labels {
+		value := asObject(options)[label]
+		if _, numeric := value.(float64); numeric {
+			values = append(values, label)
+		} else {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func anyOf(schemas ...map[string]interface{}) map[string]interface{} {
+	branches := make([]interface{}, len(schemas))
+	for i, schema := range schemas {
+		branches[i] = schema
+	}
+	return map[string]interface{}{"anyOf": branches}
+}
+
+func asObject(v interface{}) map[string]interface{} {
+	if object, ok := v.(map[string]interface{}); ok {
+		return object
+	}
+	return map[string]interface{}{}
+}
+
+func orEmpty(v interface{}) interface{} {
+	if v == nil {
+		return []interface{}{}
+	}
+	return v
+}
@@ -0,0 +1,122 @@
+package catalog_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/niolabs/gonio-blocks/catalog"
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/stretchr/testify/assert"
+)
+
+// decode round-trips a schema through JSON, as a front end would see it.
+func decode(t *testing.T, schema map[string]interface{}) map[string]interface{} {
+	b, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestSchema(t *testing.T) {
+	assert := assert.New(t)
+
+	schema, err := catalog.Schema(stdlib.Modifier.Definition)
+	if !assert.NoError(err) {
+		return
+	}
+	schema = decode(t, schema)
+
+	assert.Equal(catalog.SchemaVersion, schema["$schema"])
+	assert.Equal("Modifier", schema["title"])
+	assert.Equal("object", schema["type"])
+	assert.Equal([]interface{}{"id", "type"}, schema["required"])
+
+	properties := schema["properties"].(map[string]interface{})
+
+	exclude := properties["exclude"].(map[string]interface{})
+	assert.Equal("Exclude existing fields?", exclude["title"])
+	assert.Equal(false, exclude["default"])
+	assert.Equal("BoolType", exclude["x-nio-type"])
+	assert.Equal([]interface{}{
+		map[string]interface{}{"type": "boolean"},
+		map[string]interface{}{"type": "string", "pattern": `\{\{`},
+	}, exclude["anyOf"])
+
+	fields := properties["fields"].(map[string]interface{})
+	assert.Equal("array", fields["type"])
+	items := fields["items"].(map[string]interface{})
+	assert.NotContains(items, "required")
+	action := items["properties"].(map[string]interface{})["action"].(map[string]interface{})
+	assert.Equal([]interface{}{"delete", "set"}, action["anyOf"].([]interface{})[0].(map[string]interface{})["enum"])
+
+	logLevel := properties["log_level"].(map[string]interface{})
+	levels := logLevel["anyOf"].([]interface{})[0].(map[string]interface{})["enum"]
+	assert.Contains(levels, "NOTSET")
+	assert.Contains(levels, "DEBUG")
+
+	formula := items["properties"].(map[string]interface{})["formula"].(map[string]interface{})
+	assert.Contains(formula["anyOf"], map[string]interface{}{"type": "null"})
+}
+
+func TestSchema_Terminals(t *testing.T) {
+	assert := assert.New(t)
+
+	schema, err := catalog.Schema(stdlib.CounterIntervalSimulator.Definition)
+	if !assert.NoError(err) {
+		return
+	}
+	schema = decode(t, schema)
+
+	inputs := schema["x-nio-inputs"].([]interface{})
+	if assert.Len(inputs, 1) {
+		assert.Equal("trigger", inputs[0].(map[string]interface{})["id"])
+	}
+
+	outputs := schema["x-nio-outputs"].([]interface{})
+	if assert.Len(outputs, 1) {
+		assert.Equal("__default_terminal_value", outputs[0].(map[string]interface{})["id"])
+	}
+}
+
+func TestSchema_Commands(t *testing.T) {
+	assert := assert.New(t)
+
+	schema, err := catalog.Schema(stdlib.CounterIntervalSimulator.Definition)
+	if !assert.NoError(err) {
+		return
+	}
+	schema = decode(t, schema)
+
+	commands := schema["x-nio-commands"].(map[string]interface{})
+	assert.Contains(commands, "start")
+	assert.Contains(commands, "status")
+
+	setInterval := commands["set_interval"].(map[string]interface{})
+	assert.Equal("Set Interval", setInterval["title"])
+	assert.Equal([]interface{}{"interval"}, setInterval["required"])
+	interval := setInterval["properties"].(map[string]interface{})["interval"].(map[string]interface{})
+	assert.Equal("FloatType", interval["x-nio-type"])
+}
+
+func TestSchemas(t *testing.T) {
+	assert := assert.New(t)
+
+	entries := catalog.Entries()
+	schemas, err := catalog.Schemas(entries)
+	if !assert.NoError(err) {
+		return
+	}
+
+	assert.Len(schemas, len(entries))
+	for name, schema := range schemas {
+		assert.Equal(name, schema["title"])
+		_, err := json.Marshal(schema)
+		assert.NoError(err, name)
+	}
+}
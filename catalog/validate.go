@@ -0,0 +1,213 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PropertyError is a property of a block config that doesn't match the
+// schema of its block type. Property is the path of the property, such as
+// fields[0].title.
+type PropertyError struct {
+	Property string
+	Message  string
+}
+
+func (e PropertyError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Property, e.Message)
+}
+
+// Validate checks a block config against the schema of its block type, as
+// returned by Schema, and returns an error for every property that doesn't
+// match, ordered by property. An error is returned if config is not a JSON
+// object.
+//
+// Only the parts of JSON Schema that Schema writes are checked: type, enum,
+// pattern, anyOf, properties, required, additionalProperties and items.
+func Validate(schema map[string]interface{}, config []byte) ([]PropertyError, error) {
+	var value interface{}
+	if err := json.Unmarshal(config, &value); err != nil {
+		return nil, err
+	}
+	if _, ok := value.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("config is not an object")
+	}
+
+	normalized, err := normalize(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []PropertyError
+	validate(asObject(normalized), value, "", &errs)
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Property < errs[j].Property })
+	return errs, nil
+}
+
+func validate(schema map[string]interface{}, value interface{}, path string, errs *[]PropertyError) {
+	report := func(format string, args ...interface{}) {
+		*errs = append(*errs, PropertyError{Property: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if branches, ok := schema["anyOf"].([]interface{}); ok {
+		// when the value is of the type of one branch only, such as an
+		// object for a time delta, the errors within it are the useful
+		// ones; a string that is not an expression is just of the wrong type
+		var typed []PropertyError
+		matches := 0
+		for _, branch := range branches {
+			var branchErrs []PropertyError
+			validate(asObject(branch), value, path, &branchErrs)
+			if len(branchErrs) == 0 {
+				return
+			}
+			if _, expression := asObject(branch)["pattern"]; expression {
+				continue
+			}
+			if branchType, ok := asObject(branch)["type"].(string); ok && hasType(value, branchType) {
+				typed = branchErrs
+				matches++
+			}
+		}
+
+		if matches == 1 {
+			*errs = append(*errs, typed...)
+			return
+		}
+		report("must be %s", describe(schema))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		for _, option := range enum {
+			if option == value {
+				return
+			}
+		}
+		report("must be %s", describe(schema))
+		return
+	}
+
+	if schemaType, ok := schema["type"].(string); ok && !hasType(value, schemaType) {
+		report("must be %s", describe(schema))
+		return
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		if s, _ := value.(string); !regexp.MustCompile(pattern).MatchString(s) {
+			report("must match %s", pattern)
+			return
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties := asObject(schema["properties"])
+
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				name, _ := name.(string)
+				if _, ok := value[name]; !ok {
+					*errs = append(*errs, PropertyError{Property: join(path, name), Message: "is required"})
+				}
+			}
+		}
+
+		for name, property := range value {
+			if propertySchema, ok := properties[name]; ok {
+				validate(asObject(propertySchema), property, join(path, name), errs)
+			} else if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				*errs = append(*errs, PropertyError{Property: join(path, name), Message: "is not a known property"})
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range value {
+				validate(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+// hasType reports whether a decoded JSON value is of a JSON Schema type.
+func hasType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	default:
+		return true
+	}
+}
+
+// describe names the values a schema accepts, for error messages.
+func describe(schema map[string]interface{}) string {
+	if branches, ok := schema["anyOf"].([]interface{}); ok {
+		descriptions := make([]string, len(branches))
+		for i, branch := range branches {
+			descriptions[i] = describe(asObject(branch))
+		}
+		return strings.Join(descriptions, " or ")
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		options := make([]string, len(enum))
+		for i, option := range enum {
+			b, _ := json.Marshal(option)
+			options[i] = string(b)
+		}
+		return "one of " + strings.Join(options, ", ")
+	}
+
+	if _, ok := schema["pattern"]; ok {
+		return "an expression"
+	}
+
+	switch schema["type"] {
+	case "null":
+		return "null"
+	case "boolean":
+		return "a boolean"
+	case "string":
+		return "a string"
+	case "number":
+		return "a number"
+	case "integer":
+		return "an integer"
+	case "object":
+		return "an object"
+	case "array":
+		return "a list"
+	default:
+		return "any value"
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,101 @@
+package catalog_test
+
+import (
+	"testing"
+
+	"github.com/niolabs/gonio-blocks/catalog"
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	schema, err := catalog.Schema(stdlib.Modifier.Definition)
+	if !assert.NoError(err) {
+		return
+	}
+
+	errs, err := catalog.Validate(schema, []byte(`{
+	"type": "Modifier",
+	"id": "modifier",
+	"exclude": "{{ $hidden }}",
+	"log_level": "INFO",
+	"fields": [
+		{"title": "sum", "formula": "{{ $a + $b }}"},
+		{"title": "gone", "formula": null, "action": "delete"}
+	]
+}`))
+	assert.NoError(err)
+	assert.Empty(errs)
+}
+
+func TestValidate_PropertyErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	schema, err := catalog.Schema(stdlib.Modifier.Definition)
+	if !assert.NoError(err) {
+		return
+	}
+
+	errs, err := catalog.Validate(schema, []byte(`{
+	"type": "Modifier",
+	"exclude": "yes",
+	"chain_fields": 1,
+	"log_level": "LOUD",
+	"fields": [
+		{"title": 3, "formula": 1},
+		{"title": "x", "action": "rename"}
+	]
+}`))
+	assert.NoError(err)
+	assert.Equal([]catalog.PropertyError{
+		{Property: "chain_fields", Message: "must be a boolean or an expression"},
+		{Property: "exclude", Message: "must be a boolean or an expression"},
+		{Property: "fields[0].title", Message: "must be a string"},
+		{Property: "fields[1].action", Message: `must be one of "delete", "set" or an expression`},
+		{Property: "id", Message: "is required"},
+		{Property: "log_level", Message: `must be one of "CRITICAL", "DEBUG", "ERROR", "INFO", "NOTSET", "WARNING" or an expression`},
+	}, errs)
+}
+
+func TestValidate_TimeDelta(t *testing.T) {
+	assert := assert.New(t)
+
+	schema, err := catalog.Schema(stdlib.CounterIntervalSimulator.Definition)
+	if !assert.NoError(err) {
+		return
+	}
+
+	errs, err := catalog.Validate(schema, []byte(`{
+	"type": "CounterIntervalSimulator",
+	"id": "sim",
+	"interval": {"second": 1},
+	"num_signals": 1.5,
+	"start_delay": {"milliseconds": 50}
+}`))
+	assert.NoError(err)
+	assert.Equal([]catalog.PropertyError{
+		{Property: "interval.second", Message: "is not a known property"},
+		{Property: "num_signals", Message: "must be an integer or an expression"},
+	}, errs)
+}
+
+func TestValidate_NotAnObject(t *testing.T) {
+	schema, err := catalog.Schema(stdlib.Noop.Definition)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = catalog.Validate(schema, []byte(`[]`))
+	assert.Error(t, err)
+
+	_, err = catalog.Validate(schema, []byte(`{`))
+	assert.Error(t, err)
+}
+
+func TestPropertyError(t *testing.T) {
+	err := catalog.PropertyError{Property: "fields[0].title", Message: "is required"}
+
+	assert.EqualError(t, err, "fields[0].title: is required")
+}
@@ -53,3 +53,100 @@ func (block *SubscriberBlock) Enqueue(terminal nio.Terminal, signals nio.SignalG
 func (block *SubscriberBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
 	fn(block.TOut, block.ChOut)
 }
+
+var subscriberDefinition = nio.BlockTypeDefinition{
+	Version: "1.1.0",
+	BlockAttributes: nio.BlockAttributes{
+		Outputs: []nio.TerminalDefinition{
+			{
+				Label:   "default",
+				Type:    "output",
+				Visible: true,
+				Order:   0,
+				ID:      "__default_terminal_value",
+				Default: true,
+			},
+		},
+		Inputs: []nio.TerminalDefinition{},
+	},
+	Namespace: "blocks.communication.subscriber.Subscriber",
+	Properties: map[nio.Property]nio.PropertyDefinition{
+		"type": {
+			"order":      nil,
+			"advanced":   false,
+			"visible":    false,
+			"title":      "Type",
+			"type":       "StringType",
+			"readonly":   true,
+			"allow_none": false,
+			"default":    nil,
+		},
+		"version": {
+			"order":      nil,
+			"type":       "StringType",
+			"advanced":   true,
+			"visible":    true,
+			"default":    "1.1.0",
+			"allow_none": false,
+			"title":      "Version",
+		},
+		"topic": {
+			"order":      nil,
+			"type":       "StringType",
+			"advanced":   false,
+			"visible":    true,
+			"default":    nil,
+			"allow_none": false,
+			"title":      "Topic",
+		},
+		"id": {
+			"order":      nil,
+			"type":       "StringType",
+			"advanced":   false,
+			"visible":    false,
+			"default":    nil,
+			"allow_none": false,
+			"title":      "Id",
+		},
+		"name": {
+			"order":      nil,
+			"type":       "StringType",
+			"advanced":   false,
+			"visible":    false,
+			"default":    nil,
+			"allow_none": true,
+			"title":      "Name",
+		},
+		"log_level": {
+			"order": nil,
+			"options": map[string]int{
+				"WARNING":  30,
+				"NOTSET":   0,
+				"ERROR":    40,
+				"INFO":     20,
+				"DEBUG":    10,
+				"CRITICAL": 50,
+			},
+			"advanced":   true,
+			"visible":    true,
+			"title":      "Log Level",
+			"type":       "SelectType",
+			"enum":       "LogLevel",
+			"allow_none": false,
+			"default":    "NOTSET",
+		},
+	},
+	Commands: map[nio.Command]nio.CommandDefinition{},
+	Name:     "Subscriber",
+}
+
+func NewSubscriber(connection client.Connection) nio.BlockTypeEntry {
+	return nio.BlockTypeEntry{
+		Create: func() nio.Block {
+			return &SubscriberBlock{
+				Connection: connection,
+			}
+		},
+		Definition: subscriberDefinition,
+	}
+}
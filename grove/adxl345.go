@@ -166,10 +166,7 @@ func (b *ADXL345Block) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) e
 	return b.Transformer.Enqueue(terminal, signals, 1)
 }
 
-var DefaultADXL345 = nio.BlockTypeEntry{
-	Create:     func() nio.Block { return &ADXL345Block{bus: 0} },
-	Definition: nio.BlockTypeDefinition{},
-}
+var DefaultADXL345 = NewADXL345(0)
 
 func NewADXL345(bus uint) nio.BlockTypeEntry {
 	return nio.BlockTypeEntry{
@@ -0,0 +1,407 @@
+package stdlib
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/mixins"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// AlarmBlock raises an alarm for a group when its value goes beyond a
+// threshold, and clears it once the value comes back past another, so a
+// value hovering around a threshold does not make the alarm chatter. A high
+// alarm is raised when the value reaches high_set and cleared when it falls
+// to high_clear; a low alarm is raised when the value falls to low_set and
+// cleared when it rises to low_clear. Either may be left unset, but not
+// both, and low_set must be below high_set.
+//
+// The value must stay beyond the set threshold for min_duration before the
+// alarm is raised, and an alarm is not raised again within cooldown of being
+// cleared. Signals are emitted only when an alarm is raised or cleared,
+// carrying the alarm, high or low, the value, when the value went beyond the
+// set threshold as started_at, and the peak value since.
+type AlarmBlock struct {
+	nio.Splitter
+	mixins.GroupByMixin
+	Config AlarmBlockConfig
+
+	// Clock times the minimum duration and the cooldown. It defaults to
+	// RealClock.
+	Clock Clock
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	value       hoistedAny
+	high, low   *alarmThresholds
+	minDuration time.Duration
+	cooldown    time.Duration
+
+	mutex   sync.Mutex
+	groups  groupState
+	metrics blockMetrics
+	outbox  outbox
+}
+
+type AlarmBlockConfig struct {
+	nio.BlockConfigAtom
+	Value       *props.AnyProperty       `json:"value" order:"0" type:"FloatType"`
+	HighSet     *props.AnyProperty       `json:"high_set" title:"High Alarm Threshold" order:"1" type:"FloatType" allow_none:"true"`
+	HighClear   *props.AnyProperty       `json:"high_clear" title:"High Alarm Clear Threshold" order:"2" type:"FloatType" allow_none:"true"`
+	LowSet      *props.AnyProperty       `json:"low_set" title:"Low Alarm Threshold" order:"3" type:"FloatType" allow_none:"true"`
+	LowClear    *props.AnyProperty       `json:"low_clear" title:"Low Alarm Clear Threshold" order:"4" type:"FloatType" allow_none:"true"`
+	MinDuration *props.TimeDeltaProperty `json:"min_duration" title:"Minimum Duration" order:"5" default:"{\"seconds\": 0}"`
+	Cooldown    *props.TimeDeltaProperty `json:"cooldown" order:"6" advanced:"true" default:"{\"seconds\": 0}"`
+	GroupStateConfig
+}
+
+// The alarms a group may raise.
+const (
+	alarmHigh = "high"
+	alarmLow  = "low"
+)
+
+// alarmThresholds are the set and clear thresholds of one alarm.
+type alarmThresholds struct {
+	set, clear float64
+}
+
+// alarmEntry is the alarm state of a group. While the value is beyond a set
+// threshold the alarm is pending, until it is raised.
+type alarmEntry struct {
+	alarm     string
+	raised    bool
+	startedAt time.Time
+	peak      float64
+	last      float64
+
+	cooldownUntil time.Time
+	timer         Timer
+}
+
+func (b *AlarmBlock) Configure(config nio.RawBlockConfig) error {
+	SetTerminal(&b.TOutLeft, "raised")
+	SetTerminal(&b.TOutRight, "cleared")
+	b.Splitter.Configure()
+
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
+
+	c.add("group_by", b.GroupByMixin.Configure(config, b.notify))
+
+	if b.Config.Value == nil {
+		c.addf("value", "value is unset")
+	} else {
+		b.value, err = hoistAny(b.Config.Value, c.raw["value"], nil)
+		c.add("value", err)
+	}
+
+	b.high = alarmThresholdsOf(c, "high", b.Config.HighSet, b.Config.HighClear)
+	if b.high != nil && b.high.clear > b.high.set {
+		c.addf("high_clear", "high_clear must not be above high_set")
+	}
+	b.low = alarmThresholdsOf(c, "low", b.Config.LowSet, b.Config.LowClear)
+	if b.low != nil && b.low.clear < b.low.set {
+		c.addf("low_clear", "low_clear must not be below low_set")
+	}
+	if b.Config.HighSet == nil && b.Config.LowSet == nil {
+		c.addf("high_set", "neither high_set nor low_set is set")
+	}
+	if b.high != nil && b.low != nil && b.low.set >= b.high.set {
+		c.addf("low_set", "low_set must be below high_set")
+	}
+
+	c.add("min_duration", b.Config.MinDuration.AssignToDefault(&b.minDuration, nil, 0))
+	c.add("cooldown", b.Config.Cooldown.AssignToDefault(&b.cooldown, nil, 0))
+
+	b.groups.configure(c, &b.Config.GroupStateConfig, b.Clock, &b.mutex, b.metrics)
+	b.groups.addGroup = b.GroupByMixin.AddGroupToSignal
+	b.groups.onEvict = func(_ mixins.Group, entry interface{}) {
+		entry.(*alarmEntry).stopTimer()
+	}
+	b.outbox.configure(b.metrics)
+
+	return c.err()
+}
+
+// alarmThresholdsOf reads the thresholds of an alarm, which is disabled when
+// its set threshold is unset. The clear threshold defaults to the set one.
+func alarmThresholdsOf(c *configCheck, alarm string, set, clear *props.AnyProperty) *alarmThresholds {
+	if set == nil {
+		return nil
+	}
+
+	t := &alarmThresholds{}
+	if !c.add(alarm+"_set", assignFloatDefault(set, &t.set, 0)) {
+		return nil
+	}
+	if !c.add(alarm+"_clear", assignFloatDefault(clear, &t.clear, t.set)) {
+		return nil
+	}
+	return t
+}
+
+func (b *AlarmBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
+	return b.Consumer.Enqueue(terminal, signals, 1)
+}
+
+func (b *AlarmBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	b.Splitter.EachOutput(fn)
+	b.groups.eachOutput(fn)
+}
+
+func (b *AlarmBlock) Start(ctx context.Context) {
+	defer b.stop()
+
+	for {
+		select {
+		case signals := <-b.ChIn:
+			b.mutex.Lock()
+			b.GroupByMixin.Process(signals, b.metrics.group(b.process))
+			b.mutex.Unlock()
+			b.outbox.flush()
+			b.Busy.Done()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// notify adds the signals processed to the outbox. It is called with the
+// mutex held, as are the alarms raised later, so the signals of a group are
+// emitted in order.
+func (b *AlarmBlock) notify(terminal nio.Terminal, signals nio.SignalGroup) error {
+	ch := b.ChOutLeft
+	if terminal == b.TOutRight {
+		ch = b.ChOutRight
+	}
+	b.outbox.add(terminal, ch, signals)
+	return nil
+}
+
+// process raises and clears the alarm of a group. It must be called with the
+// mutex held.
+func (b *AlarmBlock) process(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error {
+	now := clockOrReal(b.Clock).Now()
+
+	entry := &alarmEntry{}
+	if value, ok := b.groups.get(group); ok {
+		entry = value.(*alarmEntry)
+	}
+
+	var raised, cleared nio.SignalGroup
+	for _, signal := range signals {
+		raw, err := b.value.Invoke(signal)
+		if err != nil {
+			b.metrics.expressionError("value")
+			continue
+		}
+		value, ok := toFloat(raw)
+		if !ok {
+			b.metrics.expressionError("value")
+			continue
+		}
+		entry.last = value
+
+		if entry.raised {
+			entry.peak = b.peak(entry.alarm, entry.peak, value)
+			if b.clears(entry.alarm, value) {
+				cleared = append(cleared, b.alarmSignal(group, entry))
+				entry.raised = false
+				entry.alarm = ""
+				entry.cooldownUntil = now.Add(b.cooldown)
+			}
+			continue
+		}
+
+		switch alarm := b.beyond(value); {
+		case alarm == "":
+			entry.alarm = ""
+			entry.stopTimer()
+		case alarm == entry.alarm:
+			entry.peak = b.peak(alarm, entry.peak, value)
+		default:
+			entry.alarm, entry.startedAt, entry.peak = alarm, now, value
+			entry.stopTimer()
+		}
+
+		if signal := b.raise(group, entry, now); signal != nil {
+			raised = append(raised, signal)
+		}
+	}
+
+	b.groups.set(group, entry)
+
+	if len(raised) > 0 {
+		if err := notify(b.TOutLeft, raised); err != nil {
+			return err
+		}
+	}
+	if len(cleared) > 0 {
+		return notify(b.TOutRight, cleared)
+	}
+	return nil
+}
+
+// beyond returns the alarm whose set threshold value is beyond, if any.
+func (b *AlarmBlock) beyond(value float64) string {
+	switch {
+	case b.high != nil && value >= b.high.set:
+		return alarmHigh
+	case b.low != nil && value <= b.low.set:
+		return alarmLow
+	default:
+		return ""
+	}
+}
+
+// clears reports whether value clears a raised alarm.
+func (b *AlarmBlock) clears(alarm string, value float64) bool {
+	if alarm == alarmHigh {
+		return value <= b.high.clear
+	}
+	return value >= b.low.clear
+}
+
+// peak returns the more extreme of two values for an alarm.
+func (b *AlarmBlock) peak(alarm string, peak, value float64) float64 {
+	if alarm == alarmHigh {
+		return math.Max(peak, value)
+	}
+	return math.Min(peak, value)
+}
+
+// raise raises the pending alarm of a group once it has been pending for
+// min_duration and is out of its cooldown, returning the raised signal.
+// Until then, it arms a timer to try again. It must be called with the mutex
+// held.
+func (b *AlarmBlock) raise(group mixins.Group, entry *alarmEntry, now time.Time) nio.Signal {
+	if entry.alarm == "" || entry.raised {
+		return nil
+	}
+
+	ready := entry.startedAt.Add(b.minDuration)
+	if entry.cooldownUntil.After(ready) {
+		ready = entry.cooldownUntil
+	}
+
+	if now.Before(ready) {
+		if entry.timer == nil {
+			var timer Timer
+			timer = clockOrReal(b.Clock).AfterFunc(ready.Sub(now), func() { b.raiseLater(group, entry, timer) })
+			entry.timer = timer
+		}
+		return nil
+	}
+
+	entry.stopTimer()
+	entry.raised = true
+	return b.alarmSignal(group, entry)
+}
+
+// raiseLater raises an alarm that became ready without another signal of its
+// group arriving, with the last value seen. The signal is added to the
+// outbox with the mutex held, so it precedes the cleared signal of the group,
+// and sent once it is released.
+func (b *AlarmBlock) raiseLater(group mixins.Group, entry *alarmEntry, timer Timer) {
+	defer b.outbox.flush()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// the alarm is no longer pending, or the group was evicted
+	if current, ok := b.groups.peek(group); !ok || current != entry || entry.timer != timer {
+		return
+	}
+	entry.timer = nil
+
+	if signal := b.raise(group, entry, clockOrReal(b.Clock).Now()); signal != nil {
+		b.outbox.add(b.TOutLeft, b.ChOutLeft, nio.SignalGroup{signal})
+	}
+}
+
+// alarmSignal describes the alarm of a group as of its last value.
+func (b *AlarmBlock) alarmSignal(group mixins.Group, entry *alarmEntry) nio.Signal {
+	signal := nio.Signal{
+		"alarm":      entry.alarm,
+		"value":      entry.last,
+		"started_at": entry.startedAt,
+		"peak":       entry.peak,
+	}
+	b.GroupByMixin.AddGroupToSignal(group, signal, false)
+	return signal
+}
+
+func (e *alarmEntry) stopTimer() {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+}
+
+// stop disarms every pending alarm when the block stops. Signals still in
+// the outbox are sent only if their output has room.
+func (b *AlarmBlock) stop() {
+	b.outbox.close()
+	b.outbox.flush()
+
+	b.mutex.Lock()
+	b.groups.each(func(_ mixins.Group, entry interface{}) {
+		entry.(*alarmEntry).stopTimer()
+	})
+	b.mutex.Unlock()
+
+	b.groups.stop()
+}
+
+const alarmVersion = "0.1.0"
+
+var Alarm = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &AlarmBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.alarm.alarm_block.Alarm",
+		Version:    alarmVersion,
+		Name:       "Alarm",
+		Properties: groupedProperties(AlarmBlockConfig{}, alarmVersion),
+		Commands:   map[nio.Command]nio.CommandDefinition{},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "raised",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "raised",
+					Default: true,
+				},
+				{
+					Label:   "cleared",
+					Type:    "output",
+					Visible: true,
+					Order:   1,
+					ID:      "cleared",
+					Default: false,
+				},
+				groupEvictedOutput(2),
+			},
+		},
+	},
+}
@@ -0,0 +1,261 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func temperatures(values ...float64) []nio.Signal {
+	signals := make([]nio.Signal, len(values))
+	for i, value := range values {
+		signals[i] = nio.Signal{"temperature": value}
+	}
+	return signals
+}
+
+func TestAlarmBlock_Hysteresis(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := stdlib.AlarmBlock{Clock: stdlib.NewFakeClock(start)}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Alarm",
+	"value": "{{ $temperature }}",
+	"high_set": 80,
+	"high_clear": 75
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, temperatures(70, 79.9)...)
+	takeNone(t, b.ChOutLeft, &b.Busy)
+
+	// hovering around the threshold raises the alarm once
+	put(t, &b, nio.DefaultTerminal, temperatures(80, 79, 82, 78.5, 81)...)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"alarm": "high", "value": float64(80), "started_at": start, "peak": float64(80)},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+	takeNone(t, b.ChOutRight, &b.Busy)
+
+	put(t, &b, nio.DefaultTerminal, temperatures(75, 81)...)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"alarm": "high", "value": float64(75), "started_at": start, "peak": float64(82)},
+	}, takeOne(t, b.ChOutRight, &b.Busy))
+	assert.Len(takeOne(t, b.ChOutLeft, &b.Busy), 1)
+}
+
+func TestAlarmBlock_Low(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := stdlib.AlarmBlock{Clock: stdlib.NewFakeClock(start)}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Alarm",
+	"value": "{{ $temperature }}",
+	"low_set": 5,
+	"low_clear": 8,
+	"group_by": "{{ $line }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"line": 1, "temperature": 4},
+		nio.Signal{"line": 2, "temperature": 6},
+		nio.Signal{"line": 1, "temperature": 2},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "1", "alarm": "low", "value": float64(4), "started_at": start, "peak": float64(4)},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"line": 1, "temperature": 7},
+		nio.Signal{"line": 1, "temperature": 8},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "1", "alarm": "low", "value": float64(8), "started_at": start, "peak": float64(2)},
+	}, takeOne(t, b.ChOutRight, &b.Busy))
+}
+
+func TestAlarmBlock_MinDuration(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := stdlib.NewFakeClock(start)
+	b := stdlib.AlarmBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Alarm",
+	"value": "{{ $temperature }}",
+	"high_set": 80,
+	"min_duration": {"seconds": 30}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	// a spike shorter than the minimum duration is ignored
+	put(t, &b, nio.DefaultTerminal, temperatures(85)...)
+	b.Busy.Wait()
+	clock.Advance(10 * time.Second)
+	put(t, &b, nio.DefaultTerminal, temperatures(70)...)
+	b.Busy.Wait()
+	clock.Advance(time.Minute)
+	takeNone(t, b.ChOutLeft, &b.Busy)
+
+	started := clock.Now()
+	put(t, &b, nio.DefaultTerminal, temperatures(81)...)
+	b.Busy.Wait()
+	clock.Advance(20 * time.Second)
+	put(t, &b, nio.DefaultTerminal, temperatures(90, 84)...)
+	takeNone(t, b.ChOutLeft, &b.Busy)
+
+	// raised once the value held, without waiting for another signal
+	clock.Advance(10 * time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"alarm": "high", "value": float64(84), "started_at": started, "peak": float64(90)},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+}
+
+func TestAlarmBlock_Cooldown(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.AlarmBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Alarm",
+	"value": "{{ $temperature }}",
+	"high_set": 80,
+	"cooldown": {"minutes": 5}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, temperatures(85)...)
+	takeOne(t, b.ChOutLeft, &b.Busy)
+	put(t, &b, nio.DefaultTerminal, temperatures(70)...)
+	takeOne(t, b.ChOutRight, &b.Busy)
+
+	clock.Advance(time.Minute)
+	put(t, &b, nio.DefaultTerminal, temperatures(85)...)
+	takeNone(t, b.ChOutLeft, &b.Busy)
+
+	clock.Advance(4 * time.Minute)
+	assert.Len(takeOne(t, b.ChOutLeft, &b.Busy), 1)
+}
+
+func TestAlarmBlock_RaisedOutputFull(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.AlarmBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Alarm",
+	"value": "{{ $temperature }}",
+	"high_set": 80,
+	"min_duration": {"seconds": 30},
+	"group_by": "{{ $sensor }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"sensor": "b", "temperature": 85})
+	b.Busy.Wait()
+	clock.Advance(30 * time.Second)
+	takeOne(t, b.ChOutLeft, &b.Busy)
+
+	// nothing reads the raised output
+	for len(b.ChOutLeft) < cap(b.ChOutLeft) {
+		b.ChOutLeft <- nio.SignalGroup{}
+	}
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"sensor": "a", "temperature": 85})
+	b.Busy.Wait()
+
+	// the alarm of a waits for the output without holding up the block
+	advanced := make(chan struct{})
+	go func() {
+		clock.Advance(30 * time.Second)
+		close(advanced)
+	}()
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"sensor": "b", "temperature": 70})
+	processed := make(chan struct{})
+	go func() {
+		b.Busy.Wait()
+		close(processed)
+	}()
+	select {
+	case <-processed:
+	case <-time.After(hangGuard):
+		t.Fatal("b was not processed")
+	}
+
+	for i := 0; i < cap(b.ChOutLeft); i++ {
+		<-b.ChOutLeft
+	}
+	assert.Equal("a", takeWithin(t, b.ChOutLeft)[0]["group"])
+	assert.Equal("b", takeWithin(t, b.ChOutRight)[0]["group"])
+	<-advanced
+}
+
+func TestAlarmBlock_ConfigErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.AlarmBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Alarm",
+	"high_set": 80,
+	"high_clear": 85
+}`))
+	assert.EqualError(err, "Alarm: value: value is unset; Alarm: high_clear: high_clear must not be above high_set")
+
+	b = stdlib.AlarmBlock{}
+	err = b.Configure(nio.RawBlockConfig(`{
+	"type": "Alarm",
+	"value": "{{ $temperature }}"
+}`))
+	assert.EqualError(err, "Alarm: high_set: neither high_set nor low_set is set")
+
+	b = stdlib.AlarmBlock{}
+	err = b.Configure(nio.RawBlockConfig(`{
+	"type": "Alarm",
+	"value": "{{ $temperature }}",
+	"high_set": 80,
+	"low_set": 80
+}`))
+	assert.EqualError(err, "Alarm: low_set: low_set must be below high_set")
+}
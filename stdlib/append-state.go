@@ -2,8 +2,10 @@ package stdlib
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/niolabs/gonio-framework"
 	"github.com/niolabs/gonio-framework/mixins"
@@ -15,18 +17,47 @@ type AppendStateBlock struct {
 	Config AppendStateBlockConfig
 	mixins.GroupByMixin
 
-	initialState interface{}
-	key          string
+	// Clock timestamps state changes and expires groups. It defaults to
+	// RealClock.
+	Clock Clock
 
-	previousState map[mixins.Group]interface{}
-	mutex         sync.RWMutex
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	initialState  interface{}
+	stateExpr     hoistedAny
+	key           string
+	historySize   int64
+	changedAtKey  string
+	recordedAtKey string
+	previousKey   string
+	historyLength int
+
+	history groupState
+	mutex   sync.Mutex
+	metrics blockMetrics
 }
 
 type AppendStateBlockConfig struct {
 	nio.BlockConfigAtom
-	InitialState *props.AnyProperty    `json:"initial_state"`
-	StateExpr    *props.AnyProperty    `json:"state_expr"`
-	StateName    *props.StringProperty `json:"state_name"`
+	InitialState   *props.AnyProperty    `json:"initial_state" order:"1" allow_none:"true"`
+	StateExpr      *props.AnyProperty    `json:"state_expr" title:"State" order:"0"`
+	StateName      *props.StringProperty `json:"state_name" order:"2" default:"state"`
+	HistorySize    *props.IntProperty    `json:"history_size" order:"3" advanced:"true" default:"0"`
+	ChangedAtName  *props.StringProperty `json:"changed_at_name" order:"4" advanced:"true" default:""`
+	PreviousName   *props.StringProperty `json:"previous_name" order:"5" advanced:"true" default:""`
+	RecordedAtName *props.StringProperty `json:"recorded_at_name" order:"6" advanced:"true" default:""`
+	GroupStateConfig
+}
+
+// appendStateEntry is a state value along with the time it was recorded and
+// the time the state changed to that value. Setting the value a group
+// already has appends an entry with the change time of the entry before it.
+type appendStateEntry struct {
+	value     interface{}
+	at        time.Time
+	changedAt time.Time
 }
 
 func (b *AppendStateBlock) Configure(config nio.RawBlockConfig) error {
@@ -34,79 +65,274 @@ func (b *AppendStateBlock) Configure(config nio.RawBlockConfig) error {
 	SetTerminal(&b.TInRight, "setter")
 
 	b.Joiner.Configure()
-	if err := b.GroupByMixin.Configure(config, b.Notify); err != nil {
-		return err
-	}
 
-	if err := json.Unmarshal(config, &b.Config); err != nil {
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
 		return err
 	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
 
-	if b.Config.StateExpr == nil {
-		panic("state expression is unset")
+	c.add("group_by", b.GroupByMixin.Configure(config, b.metrics.notify(b.Notify)))
+
+	c.add("initial_state", b.Config.InitialState.AssignToDefault(&b.initialState, nil, nil))
+	c.add("state_name", b.Config.StateName.AssignToDefault(&b.key, nil, "state"))
+	if c.add("history_size", b.Config.HistorySize.AssignToDefault(&b.historySize, nil, 0)) && b.historySize < 0 {
+		c.addf("history_size", "history_size must not be negative")
 	}
+	c.add("changed_at_name", b.Config.ChangedAtName.AssignToDefault(&b.changedAtKey, nil, ""))
+	c.add("previous_name", b.Config.PreviousName.AssignToDefault(&b.previousKey, nil, ""))
+	c.add("recorded_at_name", b.Config.RecordedAtName.AssignToDefault(&b.recordedAtKey, nil, ""))
 
-	if err := b.Config.InitialState.AssignToDefault(&b.initialState, nil, nil); err != nil {
-		return err
+	// keep enough entries for both the history and the previous state
+	b.historyLength = 1
+	if b.historySize > 1 {
+		b.historyLength = int(b.historySize)
+	}
+	if b.previousKey != "" && b.historyLength < 2 {
+		b.historyLength = 2
 	}
 
-	if err := b.Config.StateName.AssignToDefault(&b.key, nil, "state"); err != nil {
-		return err
+	if b.Config.StateExpr == nil {
+		c.addf("state_expr", "state expression is unset")
+	} else {
+		b.stateExpr, err = hoistAny(b.Config.StateExpr, c.raw["state_expr"], nil)
+		c.add("state_expr", err)
 	}
 
-	b.previousState = map[mixins.Group]interface{}{}
+	b.history.configure(c, &b.Config.GroupStateConfig, b.Clock, &b.mutex, b.metrics)
+	b.history.addGroup = b.GroupByMixin.AddGroupToSignal
 
-	return nil
+	return c.err()
+}
+
+func (b *AppendStateBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	b.Joiner.EachOutput(fn)
+	b.history.eachOutput(fn)
 }
 
 func (b *AppendStateBlock) Start(ctx context.Context) {
+	defer b.history.stop()
+
 	for {
 		select {
 		case signals := <-b.ChInLeft:
-			b.GroupByMixin.Process(signals, b.processGetter)
+			b.GroupByMixin.Process(signals, b.metrics.group(b.processGetter))
 			b.Busy.Done()
 		case signals := <-b.ChInRight:
-			b.GroupByMixin.Process(signals, b.processSetter)
+			b.GroupByMixin.Process(signals, b.metrics.group(b.processSetter))
 			b.Busy.Done()
+		case <-ctx.Done():
+			return
 		}
 	}
 }
 
 func (b *AppendStateBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
 	return b.Joiner.Enqueue(terminal, signals, 1)
 }
 
 func (b *AppendStateBlock) processGetter(group mixins.Group, notify nio.NotifyFunc, inSignals nio.SignalGroup) error {
-	b.mutex.RLock()
-	defer b.mutex.RUnlock()
-
-	state, ok := b.previousState[group]
-	if !ok {
-		state = b.initialState
-	}
+	b.mutex.Lock()
+	fields := b.fields(b.entries(group))
+	b.mutex.Unlock()
 
 	var outSignals nio.SignalGroup
 	for _, inSignal := range inSignals {
-		outSignal := inSignal.Clone()
-		outSignal[b.key] = state
-		outSignals = append(outSignals, outSignal)
+		outSignals = append(outSignals, inSignal.CloneWith(fields))
 	}
 
 	return notify(b.TOut, outSignals)
 }
 
+// fields returns the fields the getter adds to signals for the states of a
+// group. With a history, the states and the times they were recorded are
+// lists, from the oldest; changed_at is always when the current state was
+// first set.
+func (b *AppendStateBlock) fields(entries []appendStateEntry) nio.Signal {
+	all := entries
+
+	var state, recordedAt, changedAt interface{}
+	previous := b.initialState
+
+	if b.historySize > 0 && len(entries) == 0 {
+		// the initial state was never set, so it has no time
+		state, recordedAt = []interface{}{b.initialState}, []interface{}{nil}
+	} else if b.historySize > 0 {
+		if len(entries) > int(b.historySize) {
+			entries = entries[len(entries)-int(b.historySize):]
+		}
+
+		values := make([]interface{}, len(entries))
+		times := make([]interface{}, len(entries))
+		for i, entry := range entries {
+			values[i], times[i] = entry.value, entry.at
+		}
+		state, recordedAt = values, times
+	} else if n := len(entries); n > 0 {
+		state, recordedAt = entries[n-1].value, entries[n-1].at
+	} else {
+		state = b.initialState
+	}
+
+	if n := len(all); n > 0 {
+		changedAt = all[n-1].changedAt
+	}
+	if n := len(all); n > 1 {
+		previous = all[n-2].value
+	}
+
+	fields := nio.Signal{b.key: state}
+	if b.changedAtKey != "" {
+		fields[b.changedAtKey] = changedAt
+	}
+	if b.recordedAtKey != "" {
+		fields[b.recordedAtKey] = recordedAt
+	}
+	if b.previousKey != "" {
+		fields[b.previousKey] = previous
+	}
+	return fields
+}
+
 func (b *AppendStateBlock) processSetter(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
 	last := signals[len(signals)-1]
 
-	value, err := b.Config.StateExpr.Invoke(last)
+	value, err := b.stateExpr.Invoke(last)
 	if err != nil {
+		b.metrics.expressionError("state_expr")
 		return err
 	}
 
-	b.previousState[group] = value
-
+	b.appendState(group, value)
 	return nil
 }
+
+// appendState appends a state to the history of a group. It must be called
+// with the mutex held.
+func (b *AppendStateBlock) appendState(group mixins.Group, value interface{}) {
+	entries := b.entries(group)
+
+	now := clockOrReal(b.Clock).Now()
+	changedAt := now
+	if n := len(entries); n > 0 && reflect.DeepEqual(entries[n-1].value, value) {
+		changedAt = entries[n-1].changedAt
+	}
+
+	entries = append(entries, appendStateEntry{value: value, at: now, changedAt: changedAt})
+	if len(entries) > b.historyLength {
+		entries = entries[len(entries)-b.historyLength:]
+	}
+	b.history.set(group, entries)
+}
+
+// entries returns the states of a group, from the oldest. It must be called
+// with the mutex held.
+func (b *AppendStateBlock) entries(group mixins.Group) []appendStateEntry {
+	entries, _ := b.history.get(group)
+	history, _ := entries.([]appendStateEntry)
+	return history
+}
+
+// Command inspects or sets the state of groups. get_state returns the fields
+// the getter would add for the group given, or for every group; set_state
+// appends a state to the history of a group as a setter signal would.
+func (b *AppendStateBlock) Command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch command {
+	case "get_state":
+		if group, ok := groupArg(args); ok {
+			entries, _ := b.history.peek(group)
+			history, _ := entries.([]appendStateEntry)
+			return b.fields(history), nil
+		}
+
+		states := map[string]nio.Signal{}
+		b.history.each(func(group mixins.Group, entries interface{}) {
+			states[string(group)] = b.fields(entries.([]appendStateEntry))
+		})
+		return states, nil
+	case "set_state":
+		group, ok := groupArg(args)
+		if !ok {
+			return nil, fmt.Errorf("set_state: group is required")
+		}
+		value, ok := args["state"]
+		if !ok {
+			return nil, fmt.Errorf("set_state: state is required")
+		}
+
+		b.appendState(group, value)
+		return b.fields(b.entries(group)), nil
+	default:
+		return nil, unknownCommand(command)
+	}
+}
+
+const appendStateVersion = "0.2.0"
+
+var AppendState = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &AppendStateBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.append_state.append_state_block.AppendState",
+		Version:    appendStateVersion,
+		Name:       "AppendState",
+		Properties: groupedProperties(AppendStateBlockConfig{}, appendStateVersion),
+		Commands: map[nio.Command]nio.CommandDefinition{
+			"get_state": {
+				"title": "Get State",
+				"params": map[string]interface{}{
+					"group": groupParam(true),
+				},
+			},
+			"set_state": {
+				"title": "Set State",
+				"params": map[string]interface{}{
+					"group": groupParam(false),
+					"state": map[string]interface{}{
+						"title":      "State",
+						"type":       "Type",
+						"default":    nil,
+						"allow_none": true,
+					},
+				},
+			},
+		},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "getter",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "getter",
+					Default: true,
+				},
+				{
+					Label:   "setter",
+					Type:    "input",
+					Visible: true,
+					Order:   1,
+					ID:      "setter",
+					Default: false,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+				groupEvictedOutput(1),
+			},
+		},
+	},
+}
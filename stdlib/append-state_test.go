@@ -3,6 +3,7 @@ package stdlib_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/niolabs/gonio-framework"
 	"github.com/niolabs/gonio-blocks/stdlib"
@@ -104,3 +105,176 @@ func TestAppendStateBlock_InitialValue(t *testing.T) {
 		}
 	}
 }
+
+func TestAppendStateBlock_History(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := stdlib.NewFakeClock(start)
+	b := stdlib.AppendStateBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "AppendState",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"state_expr": "{{ $state }}",
+	"history_size": 3,
+	"changed_at_name": "changed_at",
+	"recorded_at_name": "recorded_at"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	{
+		put(t, &b, "getter", nil)
+		signals := takeOne(t, b.ChOut, &b.Busy)
+		assert.EqualValues(nio.SignalGroup{
+			{"state": []interface{}{nil}, "recorded_at": []interface{}{nil}, "changed_at": nil},
+		}, signals)
+	}
+
+	// every reading is recorded at its own time, even when it repeats the
+	// one before
+	for _, state := range []float64{1, 2, 3, 3} {
+		put(t, &b, "setter", nio.Signal{"state": state})
+		b.Busy.Wait()
+		clock.Advance(time.Second)
+	}
+
+	{
+		put(t, &b, "getter", nil)
+		assert.EqualValues(nio.SignalGroup{
+			{
+				"state":       []interface{}{2.0, 3.0, 3.0},
+				"recorded_at": []interface{}{start.Add(time.Second), start.Add(2 * time.Second), start.Add(3 * time.Second)},
+				"changed_at":  start.Add(2 * time.Second),
+			},
+		}, takeOne(t, b.ChOut, &b.Busy))
+	}
+}
+
+func TestAppendStateBlock_Previous(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := stdlib.AppendStateBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "AppendState",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"state_expr": "{{ $state }}",
+	"initial_state": 0,
+	"previous_name": "previous_state",
+	"group_by": "{{ $group }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	{
+		put(t, &b, "getter", nio.Signal{"group": "a"})
+		signals := takeOne(t, b.ChOut, &b.Busy)
+		assert.EqualValues(nio.SignalGroup{
+			{"group": "a", "state": 0.0, "previous_state": 0.0},
+		}, signals)
+	}
+
+	put(t, &b, "setter", nio.Signal{"group": "a", "state": 5.0})
+	b.Busy.Wait()
+	put(t, &b, "setter", nio.Signal{"group": "a", "state": 7.0}, nio.Signal{"group": "b", "state": 1.0})
+	b.Busy.Wait()
+
+	{
+		put(t, &b, "getter", nio.Signal{"group": "a"}, nio.Signal{"group": "b"})
+		signals := takeOne(t, b.ChOut, &b.Busy)
+		assert.EqualValues(nio.SignalGroup{
+			{"group": "a", "state": 7.0, "previous_state": 5.0},
+			{"group": "b", "state": 1.0, "previous_state": 0.0},
+		}, signals)
+	}
+}
+
+func TestAppendStateBlock_Commands(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := stdlib.NewFakeClock(start)
+	b := stdlib.AppendStateBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "AppendState",
+	"state_expr": "{{ $state }}",
+	"group_by": "{{ $group }}",
+	"initial_state": "idle",
+	"previous_name": "previous",
+	"changed_at_name": "changed_at"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, "setter", nio.Signal{"state": "running", "group": "a"})
+	b.Busy.Wait()
+
+	state, err := b.Command("get_state", map[string]interface{}{"group": "a"})
+	assert.NoError(err)
+	assert.Equal(nio.Signal{"state": "running", "previous": "idle", "changed_at": start}, state)
+
+	state, err = b.Command("set_state", map[string]interface{}{"group": "a", "state": "stopped"})
+	assert.NoError(err)
+	assert.Equal(nio.Signal{"state": "stopped", "previous": "running", "changed_at": start}, state)
+
+	put(t, &b, "getter", nio.Signal{"group": "a"})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "a", "state": "stopped", "previous": "running", "changed_at": start},
+	}, takeOne(t, b.ChOut, &b.Busy))
+
+	states, err := b.Command("get_state", nil)
+	assert.NoError(err)
+	assert.Equal(map[string]nio.Signal{
+		"a": {"state": "stopped", "previous": "running", "changed_at": start},
+	}, states)
+
+	// setting the same state again does not change when it changed
+	clock.Advance(time.Minute)
+	state, err = b.Command("set_state", map[string]interface{}{"group": "a", "state": "stopped"})
+	assert.NoError(err)
+	assert.Equal(nio.Signal{"state": "stopped", "previous": "stopped", "changed_at": start}, state)
+
+	state, err = b.Command("set_state", map[string]interface{}{"group": "a", "state": "running"})
+	assert.NoError(err)
+	assert.Equal(nio.Signal{"state": "running", "previous": "stopped", "changed_at": start.Add(time.Minute)}, state)
+
+	_, err = b.Command("set_state", map[string]interface{}{"group": "a"})
+	assert.EqualError(err, "set_state: state is required")
+}
+
+func TestAppendStateBlock_ConfigErrors(t *testing.T) {
+	b := stdlib.AppendStateBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "AppendState",
+	"state_expr": "{{ $state }}",
+	"history_size": -1
+}`))
+
+	assert.EqualError(t, err, "AppendState: history_size: history_size must not be negative")
+}
@@ -3,6 +3,8 @@ package stdlib
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/niolabs/gonio-framework"
 	"github.com/niolabs/gonio-framework/props"
@@ -11,23 +13,64 @@ import (
 type AttributeSelectorBlock struct {
 	nio.Transformer
 	Config AttributeSelectorBlockConfig
+
+	mode       hoistedBool
+	attributes []hoistedString
+	// selection is precomputed when neither the mode nor any attribute
+	// depends on the signal
+	selection map[string]struct{}
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	metrics blockMetrics
 }
 
 type AttributeSelectorBlockConfig struct {
 	nio.BlockConfigAtom
-	Mode       props.BooleanProperty    `json:"mode"`
-	Attributes props.StringPropertyList `json:"attributes"`
+	Mode       props.BooleanProperty    `json:"mode" title:"Keep Only Listed Attributes?" order:"0" default:"false"`
+	Attributes props.StringPropertyList `json:"attributes" title:"Attributes" order:"1" default:"[]"`
 }
 
 func (b *AttributeSelectorBlock) Configure(config nio.RawBlockConfig) error {
 	b.Transformer.Configure()
-	if err := json.Unmarshal(config, &b.Config); err != nil {
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
 		return err
 	}
-	return nil
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
+
+	b.mode, err = hoistBool(&b.Config.Mode, c.raw["mode"], false)
+	c.add("mode", err)
+
+	var rawAttributes []json.RawMessage
+	if attributes, ok := c.raw["attributes"]; ok {
+		if err := json.Unmarshal(attributes, &rawAttributes); err != nil {
+			// decodeConfig has reported the malformed list already
+			return c.err()
+		}
+	}
+
+	constant := b.mode.constant
+	b.attributes = make([]hoistedString, len(b.Config.Attributes))
+	for i := range b.Config.Attributes {
+		b.attributes[i], err = hoistString(&b.Config.Attributes[i], rawAttributes[i], "")
+		c.add(fmt.Sprintf("attributes[%d]", i), err)
+		constant = constant && b.attributes[i].constant
+	}
+
+	b.selection = nil
+	if constant && len(c.errs) == 0 {
+		b.selection, err = b.selectionFor(nil)
+		c.add("attributes", err)
+	}
+
+	return c.err()
 }
 
 func (b *AttributeSelectorBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
 	return b.Consumer.Enqueue(terminal, signals, 1)
 }
 
@@ -44,25 +87,23 @@ func (b *AttributeSelectorBlock) Start(ctx context.Context) {
 
 func (b *AttributeSelectorBlock) process(inSignals nio.SignalGroup) {
 	defer b.Busy.Done()
+	start := time.Now()
 
 	outSignals := make(nio.SignalGroup, 0, len(inSignals))
 
-SignalLoop:
 	for _, signal := range inSignals {
-		mode, err := b.Config.Mode.Invoke(signal)
+		mode, err := b.mode.Invoke(signal)
 		if err != nil {
-			// TODO handle error?
-			continue SignalLoop
+			b.metrics.expressionError("mode")
+			continue
 		}
 
-		selection := map[string]struct{}{}
-		for _, prop := range b.Config.Attributes {
-			attr, err := prop.Invoke(signal)
-			if err != nil {
-				// TODO handle error?
-				continue SignalLoop
+		selection := b.selection
+		if selection == nil {
+			if selection, err = b.selectionFor(signal); err != nil {
+				b.metrics.expressionError("attributes")
+				continue
 			}
-			selection[attr] = struct{}{}
 		}
 
 		// whitelist
@@ -75,5 +116,54 @@ SignalLoop:
 		}
 		outSignals = append(outSignals, outSignal)
 	}
+	b.metrics.processed(start)
+	b.metrics.out(b.TOut, outSignals)
 	b.ChOut <- outSignals
 }
+
+func (b *AttributeSelectorBlock) selectionFor(signal nio.Signal) (map[string]struct{}, error) {
+	selection := map[string]struct{}{}
+	for i := range b.attributes {
+		attr, err := b.attributes[i].Invoke(signal)
+		if err != nil {
+			return nil, err
+		}
+		selection[attr] = struct{}{}
+	}
+	return selection, nil
+}
+
+const attributeSelectorVersion = "0.1.0"
+
+var AttributeSelector = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &AttributeSelectorBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.attribute_selector.attribute_selector_block.AttributeSelector",
+		Version:    attributeSelectorVersion,
+		Name:       "AttributeSelector",
+		Properties: definitionProperties(AttributeSelectorBlockConfig{}, attributeSelectorVersion),
+		Commands:   map[nio.Command]nio.CommandDefinition{},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+		},
+	},
+}
@@ -88,3 +88,37 @@ func TestAttributeSelectorBlock_BlackList(t *testing.T) {
 		assert.EqualValues(nio.Signal{}, s)
 	}
 }
+
+func TestAttributeSelectorBlock_Dynamic(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := stdlib.AttributeSelectorBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "AttributeSelector",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"mode": true,
+	"attributes": ["foo", "{{ $keep }}"]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"foo": 1, "bar": 2, "keep": "bar"},
+		nio.Signal{"foo": 1, "bar": 2, "keep": "keep"},
+	)
+	signals := takeOne(t, b.ChOut, &b.Busy)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"foo": 1, "bar": 2},
+		nio.Signal{"foo": 1, "keep": "keep"},
+	}, signals)
+}
@@ -0,0 +1,230 @@
+package stdlib_test
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+)
+
+const benchmarkGroupSize = 100
+
+type outputBlock interface {
+	nio.Block
+	EachOutput(func(nio.Terminal, <-chan nio.SignalGroup))
+}
+
+// benchmarkBlock pushes the same signal group through a block b.N times and
+// reports throughput in signals per second and heap allocations per signal.
+// Outputs are drained continuously so that no terminal ever blocks.
+func benchmarkBlock(b *testing.B, block outputBlock, busy *sync.WaitGroup, config string, terminal nio.Terminal, signals nio.SignalGroup) {
+	if err := block.Configure(nio.RawBlockConfig(config)); err != nil {
+		b.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	block.EachOutput(func(_ nio.Terminal, ch <-chan nio.SignalGroup) {
+		go func() {
+			for {
+				select {
+				case <-ch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	})
+
+	go block.Start(ctx)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+
+	for i := 0; i < b.N; i++ {
+		if err := block.Enqueue(terminal, signals); err != nil {
+			b.Fatal(err)
+		}
+		busy.Wait()
+	}
+
+	elapsed := time.Since(start)
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+
+	total := float64(b.N * len(signals))
+	b.ReportMetric(total/elapsed.Seconds(), "signals/s")
+	b.ReportMetric(float64(after.Mallocs-before.Mallocs)/total, "allocs/signal")
+}
+
+func benchmarkSignals(fn func(i int) nio.Signal) nio.SignalGroup {
+	signals := make(nio.SignalGroup, benchmarkGroupSize)
+	for i := range signals {
+		signals[i] = fn(i)
+	}
+	return signals
+}
+
+func numberedSignals(i int) nio.Signal {
+	return nio.Signal{"a": float64(i), "b": 2.0, "group": i % 10}
+}
+
+func BenchmarkFilterBlock_Constant(b *testing.B) {
+	block := &stdlib.FilterBlock{}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "Filter",
+	"conditions": [{ "expr": true }]
+}`, nio.DefaultTerminal, benchmarkSignals(numberedSignals))
+}
+
+func BenchmarkFilterBlock_Expression(b *testing.B) {
+	block := &stdlib.FilterBlock{}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "Filter",
+	"operator": "ANY",
+	"conditions": [{ "expr": "{{ $a > 50 }}" }, { "expr": "{{ $b < 1 }}" }]
+}`, nio.DefaultTerminal, benchmarkSignals(numberedSignals))
+}
+
+func BenchmarkModifierBlock_Constant(b *testing.B) {
+	block := &stdlib.ModifierBlock{}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "Modifier",
+	"fields": [{ "title": "c", "formula": 1 }, { "title": "d.e", "formula": "x" }]
+}`, nio.DefaultTerminal, benchmarkSignals(numberedSignals))
+}
+
+func BenchmarkModifierBlock_Expression(b *testing.B) {
+	block := &stdlib.ModifierBlock{}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "Modifier",
+	"chain_fields": true,
+	"fields": [
+		{ "title": "sum", "formula": "{{ $a + $b }}" },
+		{ "title": "double", "formula": "{{ $sum * 2 }}" }
+	]
+}`, nio.DefaultTerminal, benchmarkSignals(numberedSignals))
+}
+
+func BenchmarkAttributeSelectorBlock(b *testing.B) {
+	block := &stdlib.AttributeSelectorBlock{}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "AttributeSelector",
+	"mode": true,
+	"attributes": ["a", "group"]
+}`, nio.DefaultTerminal, benchmarkSignals(numberedSignals))
+}
+
+func BenchmarkSwitchBlock(b *testing.B) {
+	block := &stdlib.SwitchBlock{}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "Switch",
+	"state_expr": "{{ $a > 1 }}",
+	"group_by": "{{ $group }}"
+}`, "getter", benchmarkSignals(numberedSignals))
+}
+
+func BenchmarkAppendStateBlock(b *testing.B) {
+	block := &stdlib.AppendStateBlock{}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "AppendState",
+	"state_expr": "{{ $a }}",
+	"group_by": "{{ $group }}"
+}`, "getter", benchmarkSignals(numberedSignals))
+}
+
+func BenchmarkCounterBlock(b *testing.B) {
+	block := &stdlib.CounterBlock{}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "Counter",
+	"group_by": "{{ $group }}"
+}`, nio.DefaultTerminal, benchmarkSignals(numberedSignals))
+}
+
+func BenchmarkDebounceBlock(b *testing.B) {
+	block := &stdlib.DebounceBlock{}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "Debounce",
+	"interval": {"milliseconds": 1},
+	"group_by": "{{ $group }}"
+}`, nio.DefaultTerminal, benchmarkSignals(numberedSignals))
+}
+
+func BenchmarkMergeStreamsBlock(b *testing.B) {
+	block := &stdlib.MergeStreamsBlock{}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "MergeStreams",
+	"notify_once": false
+}`, "input_1", benchmarkSignals(numberedSignals))
+}
+
+func BenchmarkNoopBlock(b *testing.B) {
+	block := &stdlib.NoopBlock{}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "Noop"
+}`, nio.DefaultTerminal, benchmarkSignals(numberedSignals))
+}
+
+func BenchmarkLoggerBlock(b *testing.B) {
+	block := &stdlib.LoggerBlock{Logger: log.New(ioutil.Discard, "", 0)}
+	benchmarkBlock(b, block, &block.Busy, `{
+	"type": "Logger"
+}`, nio.DefaultTerminal, benchmarkSignals(numberedSignals))
+}
+
+// benchmarkSimulator reads b.N signal groups from a simulator that emits as
+// fast as its output is read, and reports throughput in signals per second.
+func benchmarkSimulator(b *testing.B, block outputBlock, config string) {
+	if err := block.Configure(nio.RawBlockConfig(config)); err != nil {
+		b.Fatal(err)
+	}
+
+	var out <-chan nio.SignalGroup
+	block.EachOutput(func(_ nio.Terminal, ch <-chan nio.SignalGroup) {
+		out = ch
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go block.Start(ctx)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+
+	total := 0
+	for i := 0; i < b.N; i++ {
+		total += len(<-out)
+	}
+
+	b.ReportMetric(float64(total)/time.Since(start).Seconds(), "signals/s")
+}
+
+func BenchmarkCounterIntervalSimulatorBlock(b *testing.B) {
+	benchmarkSimulator(b, &stdlib.CounterIntervalSimulatorBlock{}, `{
+	"type": "CounterIntervalSimulator",
+	"interval": {"microseconds": 1},
+	"num_signals": 100,
+	"attr_value": {"start": 0, "end": 1000, "step": 1}
+}`)
+}
+
+func BenchmarkIdentityIntervalSimulatorBlock(b *testing.B) {
+	benchmarkSimulator(b, &stdlib.IdentityIntervalSimulatorBlock{}, `{
+	"type": "IdentityIntervalSimulator",
+	"interval": {"microseconds": 1},
+	"num_signals": 100
+}`)
+}
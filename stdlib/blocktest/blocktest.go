@@ -0,0 +1,280 @@
+// Package blocktest drives a single block in tests, without a nio service.
+//
+// A Harness configures a block from a Go map or YAML, starts it, pushes
+// signals into its inputs by terminal, and reads its outputs by terminal. It
+// works with any block shape: the terminals are those the block reports
+// through EachOutput and accepts through Enqueue.
+//
+//	h := blocktest.New(t, &stdlib.ModifierBlock{})
+//	if err := h.ConfigureYAML(`
+//	fields:
+//	  - title: b
+//	    formula: "{{ $a }}"
+//	`); err != nil {
+//		t.Fatal(err)
+//	}
+//	h.Start()
+//	defer h.Stop()
+//
+//	h.Push(nio.DefaultTerminal, nio.Signal{"a": 1})
+//	signals := h.Take(nio.DefaultTerminal)
+package blocktest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultTimeout bounds every wait of a Harness unless its Timeout is set.
+const DefaultTimeout = time.Second
+
+// Harness runs one block for the duration of a test.
+type Harness struct {
+	Block nio.Block
+
+	// Timeout bounds how long Take, Settle and Stop wait.
+	Timeout time.Duration
+
+	t          testing.TB
+	goroutines int
+
+	cancel  context.CancelFunc
+	stopped chan struct{}
+	outputs map[nio.Terminal]<-chan nio.SignalGroup
+}
+
+// New returns a Harness for block. It records the number of running
+// goroutines so Stop can report any the block leaks, so tests using it
+// should not run in parallel.
+func New(t testing.TB, block nio.Block) *Harness {
+	return &Harness{
+		Block:      block,
+		Timeout:    DefaultTimeout,
+		t:          t,
+		goroutines: runtime.NumGoroutine(),
+	}
+}
+
+// Configure configures the block from a map of its properties. The type and
+// id properties are filled in when missing.
+func (h *Harness) Configure(config map[string]interface{}) error {
+	properties := map[string]interface{}{
+		"type": reflect.Indirect(reflect.ValueOf(h.Block)).Type().Name(),
+		"id":   "blocktest",
+	}
+	for name, value := range config {
+		properties[name] = value
+	}
+
+	raw, err := json.Marshal(properties)
+	if err != nil {
+		return err
+	}
+	return h.Block.Configure(nio.RawBlockConfig(raw))
+}
+
+// ConfigureYAML configures the block from a YAML mapping of its properties.
+func (h *Harness) ConfigureYAML(src string) error {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(src), &config); err != nil {
+		return err
+	}
+
+	for name, value := range config {
+		converted, err := fromYAML(value)
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		config[name] = converted
+	}
+	return h.Configure(config)
+}
+
+// fromYAML converts the maps decoded by yaml.v2, which are keyed by
+// interface{}, into maps that encode to JSON.
+func fromYAML(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("key %v is not a string", k)
+			}
+			converted, err := fromYAML(v)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = converted
+		}
+		return m, nil
+	case []interface{}:
+		for i, v := range value {
+			converted, err := fromYAML(v)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = converted
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
+
+// Start starts the configured block.
+func (h *Harness) Start() {
+	h.outputs = map[nio.Terminal]<-chan nio.SignalGroup{}
+	h.Block.EachOutput(func(terminal nio.Terminal, ch <-chan nio.SignalGroup) {
+		h.outputs[terminal] = ch
+	})
+
+	var ctx context.Context
+	ctx, h.cancel = context.WithCancel(context.Background())
+	h.stopped = make(chan struct{})
+
+	go func() {
+		defer close(h.stopped)
+		h.Block.Start(ctx)
+	}()
+}
+
+// Push enqueues signals on an input terminal of the block.
+func (h *Harness) Push(terminal nio.Terminal, signals ...nio.Signal) {
+	h.t.Helper()
+
+	if err := h.Block.Enqueue(terminal, signals); err != nil {
+		h.t.Errorf("enqueue on %s: %s", terminal, err)
+	}
+}
+
+// Settle waits until the block has processed every signal pushed so far,
+// according to the Busy wait group of its nio type. Blocks without one, such
+// as producers, are always settled.
+func (h *Harness) Settle() {
+	h.t.Helper()
+
+	busy := busyOf(h.Block)
+	if busy == nil {
+		return
+	}
+
+	settled := make(chan struct{})
+	go func() {
+		busy.Wait()
+		close(settled)
+	}()
+
+	select {
+	case <-settled:
+	case <-time.After(h.Timeout):
+		h.t.Errorf("block did not settle within %s", h.Timeout)
+	}
+}
+
+// busyOf finds the Busy wait group of a block's embedded nio type.
+func busyOf(block nio.Block) *sync.WaitGroup {
+	v := reflect.Indirect(reflect.ValueOf(block))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	field := v.FieldByName("Busy")
+	if !field.IsValid() || !field.CanAddr() {
+		return nil
+	}
+
+	busy, _ := field.Addr().Interface().(*sync.WaitGroup)
+	return busy
+}
+
+// Take returns the next signal group emitted on an output terminal, waiting
+// up to the Timeout for it.
+func (h *Harness) Take(terminal nio.Terminal) nio.SignalGroup {
+	h.t.Helper()
+
+	ch := h.output(terminal)
+	if ch == nil {
+		return nil
+	}
+
+	select {
+	case signals := <-ch:
+		return signals
+	case <-time.After(h.Timeout):
+		h.t.Errorf("no signals on %s within %s", terminal, h.Timeout)
+		return nil
+	}
+}
+
+// TakeNone settles the block and checks that nothing is waiting on an output
+// terminal.
+func (h *Harness) TakeNone(terminal nio.Terminal) {
+	h.t.Helper()
+
+	ch := h.output(terminal)
+	if ch == nil {
+		return
+	}
+
+	h.Settle()
+	select {
+	case signals := <-ch:
+		h.t.Errorf("%d signals on %s", len(signals), terminal)
+	default:
+	}
+}
+
+func (h *Harness) output(terminal nio.Terminal) <-chan nio.SignalGroup {
+	h.t.Helper()
+
+	if h.outputs == nil {
+		h.t.Errorf("block is not started")
+		return nil
+	}
+
+	ch, ok := h.outputs[terminal]
+	if !ok {
+		h.t.Errorf("block has no output %s", terminal)
+		return nil
+	}
+	return ch
+}
+
+// Stop cancels the block, waits for Start to return, and checks that the
+// block has not left goroutines running.
+func (h *Harness) Stop() {
+	h.t.Helper()
+
+	if h.cancel == nil {
+		return
+	}
+	h.cancel()
+
+	select {
+	case <-h.stopped:
+	case <-time.After(h.Timeout):
+		h.t.Errorf("block did not stop within %s", h.Timeout)
+		return
+	}
+
+	deadline := time.Now().Add(h.Timeout)
+	for runtime.NumGoroutine() > h.goroutines {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			buf = buf[:runtime.Stack(buf, true)]
+			h.t.Errorf("block leaked %d goroutines:\n%s", runtime.NumGoroutine()-h.goroutines, buf)
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,147 @@
+package blocktest_test
+
+import (
+	"bytes"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-blocks/stdlib/blocktest"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHarness_Transformer(t *testing.T) {
+	assert := assert.New(t)
+
+	h := blocktest.New(t, &stdlib.ModifierBlock{})
+	if err := h.ConfigureYAML(`
+exclude: true
+fields:
+  - title: b
+    formula: "{{ $a }}"
+`); err != nil {
+		t.Fatal(err)
+	}
+	h.Start()
+	defer h.Stop()
+
+	h.Push(nio.DefaultTerminal, nio.Signal{"a": 1})
+	assert.EqualValues(nio.SignalGroup{{"b": 1}}, h.Take(nio.DefaultTerminal))
+	h.TakeNone(nio.DefaultTerminal)
+}
+
+func TestHarness_Splitter(t *testing.T) {
+	assert := assert.New(t)
+
+	h := blocktest.New(t, &stdlib.FilterBlock{})
+	if err := h.Configure(map[string]interface{}{
+		"conditions": []map[string]interface{}{
+			{"expr": "{{ $a > 1 }}"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h.Start()
+	defer h.Stop()
+
+	h.Push(nio.DefaultTerminal, nio.Signal{"a": 1}, nio.Signal{"a": 2})
+	assert.EqualValues(nio.SignalGroup{{"a": 2}}, h.Take("true"))
+	assert.EqualValues(nio.SignalGroup{{"a": 1}}, h.Take("false"))
+}
+
+func TestHarness_Joiner(t *testing.T) {
+	assert := assert.New(t)
+
+	h := blocktest.New(t, &stdlib.MergeStreamsBlock{})
+	if err := h.Configure(nil); err != nil {
+		t.Fatal(err)
+	}
+	h.Start()
+	defer h.Stop()
+
+	h.Push("input_1", nio.Signal{"foo": 1})
+	h.TakeNone(nio.DefaultTerminal)
+
+	h.Push("input_2", nio.Signal{"bar": 1})
+	assert.EqualValues(nio.SignalGroup{{"foo": 1, "bar": 1}}, h.Take(nio.DefaultTerminal))
+}
+
+func TestHarness_DualTransformer(t *testing.T) {
+	assert := assert.New(t)
+
+	h := blocktest.New(t, &stdlib.SwitchBlock{})
+	if err := h.Configure(map[string]interface{}{
+		"state_expr": "{{ $state }}",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h.Start()
+	defer h.Stop()
+
+	h.Push("setter", nio.Signal{"state": true})
+	h.Settle()
+	assert.EqualValues(nio.SignalGroup{{"state": true}}, h.Take("state"))
+
+	h.Push("getter", nio.Signal{"a": 1})
+	assert.EqualValues(nio.SignalGroup{{"a": 1}}, h.Take("true"))
+	h.TakeNone("false")
+}
+
+func TestHarness_Producer(t *testing.T) {
+	assert := assert.New(t)
+
+	clock := stdlib.NewFakeClock(time.Now())
+	h := blocktest.New(t, &stdlib.CounterIntervalSimulatorBlock{Clock: clock})
+	if err := h.ConfigureYAML(`
+interval:
+  seconds: 1
+attr_name: count
+`); err != nil {
+		t.Fatal(err)
+	}
+	h.Start()
+	defer h.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	assert.EqualValues(nio.SignalGroup{{"count": int64(0)}}, h.Take(nio.DefaultTerminal))
+}
+
+func TestHarness_Consumer(t *testing.T) {
+	var buffer bytes.Buffer
+
+	h := blocktest.New(t, &stdlib.LoggerBlock{Logger: log.New(&buffer, "", 0)})
+	if err := h.Configure(nil); err != nil {
+		t.Fatal(err)
+	}
+	h.Start()
+	defer h.Stop()
+
+	h.Push(nio.DefaultTerminal, nio.Signal{"a": 1})
+	h.Settle()
+	assert.Equal(t, "map[a:1]\n", buffer.String())
+}
+
+func TestHarness_ConfigureError(t *testing.T) {
+	h := blocktest.New(t, &stdlib.FilterBlock{})
+	assert.Error(t, h.Configure(map[string]interface{}{"operator": "SOME"}))
+	assert.Error(t, h.ConfigureYAML("{1: a}"))
+}
+
+func TestHarness_Stop(t *testing.T) {
+	h := blocktest.New(t, &stdlib.AppendStateBlock{})
+	if err := h.Configure(map[string]interface{}{
+		"state_expr": "{{ $state }}",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h.Start()
+
+	h.Push("setter", nio.Signal{"state": 1})
+	h.Settle()
+
+	// Stop reports a block that keeps running after its context ends
+	h.Stop()
+}
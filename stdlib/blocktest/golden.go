@@ -0,0 +1,49 @@
+package blocktest
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("blocktest.update", false, "rewrite golden files with the current results")
+
+// AssertGolden compares got, encoded as JSON, with the contents of the golden
+// file at path. Values are compared as decoded JSON, so number types and key
+// order do not matter. Running the tests with -blocktest.update rewrites the
+// golden file instead.
+func AssertGolden(t testing.TB, path string, got interface{}) {
+	t.Helper()
+
+	actual, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual = append(actual, '\n')
+
+	if *update {
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s (run with -blocktest.update to create it)", err)
+	}
+
+	var want, have interface{}
+	if err := json.Unmarshal(expected, &want); err != nil {
+		t.Fatalf("%s: %s", path, err)
+	}
+	if err := json.Unmarshal(actual, &have); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("results differ from %s\n--- expected\n%s\n+++ actual\n%s", path, expected, actual)
+	}
+}
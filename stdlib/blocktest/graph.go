@@ -0,0 +1,305 @@
+package blocktest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+)
+
+// GraphConfig describes a flow of blocks in the shape of a service config:
+// block configs keyed by block name, and the links between their terminals.
+type GraphConfig struct {
+	Blocks map[string]nio.RawBlockConfig `json:"blocks"`
+	Links  []Link                        `json:"links"`
+}
+
+// Link connects an output terminal of one block to an input terminal of
+// another. Unset terminals are the default terminal.
+type Link struct {
+	From         string       `json:"from"`
+	FromTerminal nio.Terminal `json:"from_terminal"`
+	To           string       `json:"to"`
+	ToTerminal   nio.Terminal `json:"to_terminal"`
+}
+
+// Outputs are the signal groups a graph emitted, by block name and output
+// terminal, in the order they were emitted.
+type Outputs map[string]map[nio.Terminal][]nio.SignalGroup
+
+// Graph runs a flow of blocks in process. Every signal group emitted by a
+// block is recorded and passed on to the blocks linked to the terminal it was
+// emitted on.
+//
+// A graph runs until it is quiescent: every block that finishes on its own,
+// such as a simulator with a total, has finished, and every other block has
+// processed all of its input. Blocks that emit from timers of their own are
+// not waited for.
+type Graph struct {
+	// Timeout bounds how long Run waits for the graph to become quiescent.
+	Timeout time.Duration
+
+	t      testing.TB
+	names  []string
+	blocks map[string]nio.Block
+	links  map[string]map[nio.Terminal][]Link
+}
+
+// NewGraph creates and configures the blocks of a graph. Each block config
+// names its type, which is looked up in types.
+func NewGraph(t testing.TB, types map[string]nio.BlockTypeEntry, config GraphConfig) (*Graph, error) {
+	g := &Graph{
+		Timeout: 5 * DefaultTimeout,
+		t:       t,
+		blocks:  map[string]nio.Block{},
+		links:   map[string]map[nio.Terminal][]Link{},
+	}
+
+	for name, raw := range config.Blocks {
+		var atom nio.BlockConfigAtom
+		if err := json.Unmarshal(raw, &atom); err != nil {
+			return nil, fmt.Errorf("block %s: %s", name, err)
+		}
+
+		entry, ok := types[atom.Type]
+		if !ok {
+			return nil, fmt.Errorf("block %s: unknown type `%s'", name, atom.Type)
+		}
+
+		block := entry.Create()
+		if err := block.Configure(raw); err != nil {
+			return nil, fmt.Errorf("block %s: %s", name, err)
+		}
+
+		g.names = append(g.names, name)
+		g.blocks[name] = block
+	}
+	sort.Strings(g.names)
+
+	for _, link := range config.Links {
+		if link.FromTerminal == "" {
+			link.FromTerminal = nio.DefaultTerminal
+		}
+		if link.ToTerminal == "" {
+			link.ToTerminal = nio.DefaultTerminal
+		}
+
+		if _, ok := g.blocks[link.From]; !ok {
+			return nil, fmt.Errorf("link from unknown block %s", link.From)
+		}
+		if _, ok := g.blocks[link.To]; !ok {
+			return nil, fmt.Errorf("link to unknown block %s", link.To)
+		}
+
+		if g.links[link.From] == nil {
+			g.links[link.From] = map[nio.Terminal][]Link{}
+		}
+		g.links[link.From][link.FromTerminal] = append(g.links[link.From][link.FromTerminal], link)
+	}
+
+	return g, nil
+}
+
+// LoadGraph reads a GraphConfig from JSON and creates its graph.
+func LoadGraph(t testing.TB, types map[string]nio.BlockTypeEntry, config []byte) (*Graph, error) {
+	var c GraphConfig
+	if err := json.Unmarshal(config, &c); err != nil {
+		return nil, err
+	}
+	return NewGraph(t, types, c)
+}
+
+// Block returns a block of the graph by name, for instance to control it
+// while the graph runs.
+func (g *Graph) Block(name string) nio.Block {
+	return g.blocks[name]
+}
+
+// graphOutput is an output terminal of a block in a running graph.
+type graphOutput struct {
+	block    string
+	terminal nio.Terminal
+	ch       <-chan nio.SignalGroup
+}
+
+// Run starts every block, forwards their outputs along the links until the
+// graph is quiescent, then stops every block and returns what they emitted.
+func (g *Graph) Run() Outputs {
+	g.t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var (
+		outputs  []graphOutput
+		running  sync.WaitGroup
+		finished sync.WaitGroup
+	)
+
+	for _, name := range g.names {
+		block := g.blocks[name]
+		block.EachOutput(func(terminal nio.Terminal, ch <-chan nio.SignalGroup) {
+			outputs = append(outputs, graphOutput{block: name, terminal: terminal, ch: ch})
+		})
+
+		// blocks without a Busy wait group, such as producers, are
+		// waited for to finish on their own
+		ownPace := busyOf(block) == nil
+		if ownPace {
+			finished.Add(1)
+		}
+
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			block.Start(ctx)
+			if ownPace {
+				finished.Done()
+			}
+		}()
+	}
+
+	producersDone := make(chan struct{})
+	go func() {
+		finished.Wait()
+		close(producersDone)
+	}()
+
+	cases := make([]reflect.SelectCase, len(outputs))
+	for i, output := range outputs {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(output.ch)}
+	}
+
+	timeout := time.NewTimer(g.Timeout)
+	defer timeout.Stop()
+
+	recorded := Outputs{}
+	var pending []drainedGroup
+
+	for {
+		// the outputs are drained while forwarding and while the blocks
+		// settle, as a block may have to emit before it can go on. What
+		// they emit is forwarded once they have settled, as enqueuing
+		// must not race waiting for them, or every millisecond while
+		// producers are running.
+		stopDrain, drained := drain(cases)
+
+		for _, emitted := range pending {
+			g.forward(recorded, outputs[emitted.output], emitted.signals)
+		}
+
+		var settled <-chan struct{}
+		select {
+		case <-producersDone:
+			settled = g.settle()
+		default:
+		}
+
+		var poll <-chan time.Time
+		if settled == nil {
+			poll = time.After(time.Millisecond)
+		}
+
+		select {
+		case <-settled:
+		case <-poll:
+		case <-timeout.C:
+			g.t.Errorf("graph did not become quiescent within %s", g.Timeout)
+			stopDrain()
+			g.stop(cancel, &running, cases)
+			return recorded
+		}
+
+		stopDrain()
+		if settled != nil && len(*drained) == 0 && !ready(cases) {
+			break
+		}
+		pending = *drained
+	}
+
+	g.stop(cancel, &running, cases)
+	return recorded
+}
+
+// drainedGroup is a signal group drained from one of the outputs of a graph.
+type drainedGroup struct {
+	output  int
+	signals nio.SignalGroup
+}
+
+// drain receives from the outputs of a graph until stop is called, and
+// returns what it received once it has stopped.
+func drain(cases []reflect.SelectCase) (stop func(), drained *[]drainedGroup) {
+	drained = &[]drainedGroup{}
+	quit, done := make(chan struct{}), make(chan struct{})
+
+	cases = append(cases[:len(cases):len(cases)], reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(quit)})
+	go func() {
+		defer close(done)
+		for {
+			chosen, value, _ := reflect.Select(cases)
+			if chosen == len(cases)-1 {
+				return
+			}
+			*drained = append(*drained, drainedGroup{output: chosen, signals: value.Interface().(nio.SignalGroup)})
+		}
+	}()
+
+	return func() {
+		close(quit)
+		<-done
+	}, drained
+}
+
+// forward records a signal group emitted on an output and enqueues it on the
+// blocks linked to the output.
+func (g *Graph) forward(recorded Outputs, output graphOutput, signals nio.SignalGroup) {
+	if recorded[output.block] == nil {
+		recorded[output.block] = map[nio.Terminal][]nio.SignalGroup{}
+	}
+	recorded[output.block][output.terminal] = append(recorded[output.block][output.terminal], signals)
+
+	for _, link := range g.links[output.block][output.terminal] {
+		if err := g.blocks[link.To].Enqueue(link.ToTerminal, signals); err != nil {
+			g.t.Errorf("%s.%s -> %s.%s: %s", link.From, link.FromTerminal, link.To, link.ToTerminal, err)
+		}
+	}
+}
+
+// stop stops every block, discarding what they emit as they stop.
+func (g *Graph) stop(cancel context.CancelFunc, running *sync.WaitGroup, cases []reflect.SelectCase) {
+	stopDrain, _ := drain(cases)
+	cancel()
+	running.Wait()
+	stopDrain()
+}
+
+// settle returns a channel that is closed once every block that is not a
+// producer has processed all of its input.
+func (g *Graph) settle() <-chan struct{} {
+	settled := make(chan struct{})
+	go func() {
+		for _, name := range g.names {
+			if busy := busyOf(g.blocks[name]); busy != nil {
+				busy.Wait()
+			}
+		}
+		close(settled)
+	}()
+	return settled
+}
+
+// ready reports whether any of the output channels has signals waiting.
+func ready(cases []reflect.SelectCase) bool {
+	for _, c := range cases {
+		if c.Chan.Len() > 0 {
+			return true
+		}
+	}
+	return false
+}
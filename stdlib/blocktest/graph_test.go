@@ -0,0 +1,87 @@
+package blocktest_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-blocks/stdlib/blocktest"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+var graphTypes = map[string]nio.BlockTypeEntry{
+	"IdentityIntervalSimulator": stdlib.IdentityIntervalSimulator,
+	"Noop":                      stdlib.Noop,
+}
+
+func TestGraph_Run(t *testing.T) {
+	assert := assert.New(t)
+
+	g, err := blocktest.LoadGraph(t, graphTypes, []byte(`{
+	"blocks": {
+		"sim": {"type": "IdentityIntervalSimulator", "interval": {"milliseconds": 1}, "num_signals": 2, "total_signals": 4},
+		"a": {"type": "Noop"},
+		"b": {"type": "Noop"}
+	},
+	"links": [
+		{"from": "sim", "to": "a"},
+		{"from": "sim", "to": "b"},
+		{"from": "a", "to": "b"}
+	]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := g.Run()
+	assert.Len(outputs["sim"][nio.DefaultTerminal], 2)
+	assert.Len(outputs["a"][nio.DefaultTerminal], 2)
+	assert.Len(outputs["b"][nio.DefaultTerminal], 4, "b receives from both the simulator and a")
+}
+
+// errorsTB records the errors of a test that is expected to fail.
+type errorsTB struct {
+	testing.TB
+	errors []string
+}
+
+func (t *errorsTB) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestGraph_Timeout(t *testing.T) {
+	assert := assert.New(t)
+
+	// a simulator without a total never finishes
+	tb := &errorsTB{TB: t}
+	g, err := blocktest.LoadGraph(tb, graphTypes, []byte(`{
+	"blocks": {
+		"sim": {"type": "IdentityIntervalSimulator", "interval": {"milliseconds": 1}},
+		"a": {"type": "Noop"}
+	},
+	"links": [{"from": "sim", "to": "a"}]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Timeout = 50 * time.Millisecond
+
+	outputs := g.Run()
+	assert.Equal([]string{"graph did not become quiescent within 50ms"}, tb.errors)
+	assert.NotEmpty(outputs["a"][nio.DefaultTerminal])
+}
+
+func TestGraph_Invalid(t *testing.T) {
+	_, err := blocktest.LoadGraph(t, graphTypes, []byte(`{
+	"blocks": {"x": {"type": "Unknown"}}
+}`))
+	assert.Error(t, err)
+
+	_, err = blocktest.LoadGraph(t, graphTypes, []byte(`{
+	"blocks": {"a": {"type": "Noop"}},
+	"links": [{"from": "a", "to": "missing"}]
+}`))
+	assert.Error(t, err)
+}
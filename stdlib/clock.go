@@ -0,0 +1,181 @@
+package stdlib
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for blocks that wait or timestamp. Blocks
+// with a Clock field use RealClock when it is unset; tests set a FakeClock
+// to control time deterministically.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is the subset of time.Timer that blocks use. C returns nil for
+// timers created by AfterFunc.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// RealClock is the Clock backed by the time package.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// clockOrReal returns c, or RealClock when c is unset.
+func clockOrReal(c Clock) Clock {
+	if c == nil {
+		return RealClock
+	}
+	return c
+}
+
+// FakeClock is a Clock that only moves when advanced. Timers fire during
+// Advance, in the order they are due, and functions given to AfterFunc run on
+// the goroutine calling Advance.
+type FakeClock struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock reading now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mutex)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{clock: c, f: f}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every timer that falls due on
+// the way.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	end := c.now.Add(d)
+
+	for {
+		sort.SliceStable(c.timers, func(i, j int) bool {
+			return c.timers[i].due.Before(c.timers[j].due)
+		})
+
+		if len(c.timers) == 0 || c.timers[0].due.After(end) {
+			break
+		}
+
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		c.now = t.due
+		c.cond.Broadcast()
+
+		if t.f != nil {
+			c.mutex.Unlock()
+			t.f()
+			c.mutex.Lock()
+		} else {
+			select {
+			case t.c <- c.now:
+			default:
+			}
+		}
+	}
+
+	c.now = end
+	c.mutex.Unlock()
+}
+
+// BlockUntil waits until at least n timers are pending. It lets a test wait
+// for a block's goroutine to start waiting before advancing the clock.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	c     chan time.Time
+	f     func()
+	due   time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	return t.remove()
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	active := t.remove()
+	t.due = t.clock.now.Add(d)
+	t.clock.timers = append(t.clock.timers, t)
+	t.clock.cond.Broadcast()
+	return active
+}
+
+// remove unschedules the timer, reporting whether it was pending. It must be
+// called with the clock's mutex held.
+func (t *fakeTimer) remove() bool {
+	for i, pending := range t.clock.timers {
+		if pending == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package stdlib_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := stdlib.NewFakeClock(start)
+
+	var fired []time.Time
+	clock.AfterFunc(20*time.Millisecond, func() { fired = append(fired, clock.Now()) })
+	stopped := clock.AfterFunc(10*time.Millisecond, func() { t.Error("stopped timer fired") })
+	timer := clock.NewTimer(10 * time.Millisecond)
+
+	assert.True(stopped.Stop())
+	assert.False(stopped.Stop())
+
+	clock.Advance(15 * time.Millisecond)
+	assert.Equal(start.Add(15*time.Millisecond), clock.Now())
+	assert.Empty(fired)
+
+	select {
+	case at := <-timer.C():
+		assert.Equal(start.Add(10*time.Millisecond), at, "timers fire at the time they are due")
+	default:
+		t.Error("timer has not fired")
+	}
+
+	assert.False(timer.Reset(10 * time.Millisecond))
+
+	clock.Advance(10 * time.Millisecond)
+	assert.Equal([]time.Time{start.Add(20 * time.Millisecond)}, fired)
+	assert.Len(timer.C(), 1)
+}
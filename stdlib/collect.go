@@ -0,0 +1,142 @@
+package stdlib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/mixins"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// CollectBlock is the inverse of ExplodeBlock: it gathers the signals of
+// each group in a signal group into a single signal, whose attributes are
+// lists of the values of the signals, in order. A signal lacking an
+// attribute contributes null, so the lists line up.
+//
+// When attributes is set, only the attributes listed, which may be dotted
+// paths, are collected; the other attributes are those of the first signal
+// of the group. Otherwise every attribute is collected.
+type CollectBlock struct {
+	nio.Transformer
+	mixins.GroupByMixin
+	Config CollectBlockConfig
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	attributes []string
+
+	metrics blockMetrics
+}
+
+type CollectBlockConfig struct {
+	nio.BlockConfigAtom
+	Attributes props.StringPropertyList `json:"attributes" order:"0" default:"[]"`
+}
+
+func (b *CollectBlock) Configure(config nio.RawBlockConfig) error {
+	b.Transformer.Configure()
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
+
+	c.add("group_by", b.GroupByMixin.Configure(config, b.metrics.notify(b.Notify)))
+
+	b.attributes = make([]string, len(b.Config.Attributes))
+	for i := range b.Config.Attributes {
+		property := fmt.Sprintf("attributes[%d]", i)
+		if c.add(property, b.Config.Attributes[i].AssignToDefault(&b.attributes[i], nil, "")) && b.attributes[i] == "" {
+			c.addf(property, "attribute is empty")
+		}
+	}
+
+	return c.err()
+}
+
+func (b *CollectBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
+	return b.Transformer.Enqueue(terminal, signals, 1)
+}
+
+func (b *CollectBlock) Start(ctx context.Context) {
+	for {
+		select {
+		case signals := <-b.ChIn:
+			b.GroupByMixin.Process(signals, b.metrics.group(b.process))
+			b.Busy.Done()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *CollectBlock) process(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error {
+	collected := nio.Signal{}
+
+	attributes := b.attributes
+	if len(attributes) == 0 {
+		seen := map[string]bool{}
+		for _, signal := range signals {
+			for key := range signal {
+				if !seen[key] {
+					seen[key] = true
+					attributes = append(attributes, key)
+				}
+			}
+		}
+	} else {
+		for key, value := range signals[0] {
+			collected[key] = value
+		}
+	}
+
+	for _, attribute := range attributes {
+		values := make([]interface{}, len(signals))
+		for i, signal := range signals {
+			values[i], _ = getPath(signal, attribute)
+		}
+		setPath(collected, attribute, values)
+	}
+
+	b.GroupByMixin.AddGroupToSignal(group, collected, false)
+	return notify(b.TOut, nio.SignalGroup{collected})
+}
+
+const collectVersion = "0.1.0"
+
+var Collect = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &CollectBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.collect.collect_block.Collect",
+		Version:    collectVersion,
+		Name:       "Collect",
+		Properties: groupedProperties(CollectBlockConfig{}, collectVersion),
+		Commands:   map[nio.Command]nio.CommandDefinition{},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+		},
+	},
+}
@@ -0,0 +1,89 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectBlock_Basic(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.CollectBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Collect"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"value": 1, "unit": "C"},
+		nio.Signal{"value": 2},
+		nio.Signal{"value": 3, "unit": "F"},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{
+			"value": []interface{}{1, 2, 3},
+			"unit":  []interface{}{"C", nil, "F"},
+		},
+	}, takeOne(t, b.ChOut, &b.Busy))
+}
+
+func TestCollectBlock_Attributes(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.CollectBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Collect",
+	"attributes": ["payload.readings", "index"],
+	"group_by": "{{ $device }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"device": "a", "index": 0, "payload": map[string]interface{}{"unit": "C", "readings": 20.5}},
+		nio.Signal{"device": "b", "index": 0, "payload": map[string]interface{}{"unit": "F", "readings": 70}},
+		nio.Signal{"device": "a", "index": 1, "payload": map[string]interface{}{"unit": "C", "readings": 21.0}},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{
+			"device":  "a",
+			"group":   "a",
+			"index":   []interface{}{0, 1},
+			"payload": map[string]interface{}{"unit": "C", "readings": []interface{}{20.5, 21.0}},
+		},
+		nio.Signal{
+			"device":  "b",
+			"group":   "b",
+			"index":   []interface{}{0},
+			"payload": map[string]interface{}{"unit": "F", "readings": []interface{}{70}},
+		},
+	}, takeOne(t, b.ChOut, &b.Busy))
+}
+
+func TestCollectBlock_ConfigErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.CollectBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Collect",
+	"attributes": ["value", ""]
+}`))
+	assert.EqualError(err, "Collect: attributes[1]: attribute is empty")
+}
@@ -0,0 +1,71 @@
+package stdlib
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/mixins"
+)
+
+// Commander is implemented by blocks that accept commands while they run.
+// The result of a command is returned to whoever issued it, and must encode
+// to JSON.
+type Commander interface {
+	Command(command nio.Command, args map[string]interface{}) (interface{}, error)
+}
+
+var (
+	_ Commander = &IdentityIntervalSimulatorBlock{}
+	_ Commander = &CounterIntervalSimulatorBlock{}
+	_ Commander = &RandomIntervalSimulatorBlock{}
+	_ Commander = &WaveformIntervalSimulatorBlock{}
+	_ Commander = &ReplaySimulatorBlock{}
+	_ Commander = &CounterBlock{}
+	_ Commander = &DebounceBlock{}
+	_ Commander = &SwitchBlock{}
+	_ Commander = &AppendStateBlock{}
+	_ Commander = &MergeStreamsBlock{}
+	_ Commander = &OnChangeBlock{}
+	_ Commander = &DedupeBlock{}
+)
+
+// groupParam is the definition of the group argument of the commands of
+// grouped blocks. Optional groups default to every group.
+func groupParam(optional bool) map[string]interface{} {
+	return map[string]interface{}{
+		"title":      "Group",
+		"type":       "StringType",
+		"default":    nil,
+		"allow_none": optional,
+	}
+}
+
+// groupArg returns the group argument of a command, and whether it was given.
+// Like the GroupByMixin, it groups by the string form of the value.
+func groupArg(args map[string]interface{}) (mixins.Group, bool) {
+	value, ok := args["group"]
+	if !ok || value == nil {
+		return "", false
+	}
+	return mixins.Group(fmt.Sprint(value)), true
+}
+
+// boolArg converts a command argument to a bool. Arguments given as strings,
+// as they are over the REST API, are parsed.
+func boolArg(value interface{}) (bool, bool) {
+	switch value := value.(type) {
+	case bool:
+		return value, true
+	case string:
+		parsed, err := strconv.ParseBool(value)
+		return parsed, err == nil
+	default:
+		return false, false
+	}
+}
+
+// unknownCommand is the error of commands a block does not accept.
+func unknownCommand(command nio.Command) error {
+	return fmt.Errorf("unknown command `%s'", command)
+}
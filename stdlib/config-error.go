@@ -0,0 +1,198 @@
+package stdlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/niolabs/gonio-framework"
+)
+
+// ConfigError is a misconfigured property of a block. Property is the path of
+// the property within the block config, such as conditions[1].expr, and is
+// empty when the config as a whole is at fault.
+type ConfigError struct {
+	BlockType string
+	Block     string
+	Property  string
+	Reason    string
+}
+
+func (e ConfigError) Error() string {
+	var parts []string
+	if block := strings.TrimSpace(e.BlockType + " " + quoteName(e.Block)); block != "" {
+		parts = append(parts, block)
+	}
+	if e.Property != "" {
+		parts = append(parts, e.Property)
+	}
+	return strings.Join(append(parts, e.Reason), ": ")
+}
+
+func quoteName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+// ConfigErrors are all the misconfigured properties of a block, in the order
+// they were found. Configure returns them rather than stopping at the first.
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// configCheck collects the errors of a block config as it is configured.
+type configCheck struct {
+	atom nio.BlockConfigAtom
+	errs ConfigErrors
+
+	// raw are the raw values of the properties of the config.
+	raw map[string]json.RawMessage
+}
+
+// decodeConfig decodes a block config into dst one property at a time, so
+// every property that fails to decode is reported by its path, and the rest
+// are still decoded. An error is returned only when the config is not a JSON
+// object at all; otherwise the check goes on collecting errors until err is
+// called.
+func decodeConfig(config nio.RawBlockConfig, dst interface{}) (*configCheck, error) {
+	c := &configCheck{}
+
+	if err := json.Unmarshal(config, &c.raw); err != nil || c.raw == nil {
+		// the atom may still be readable when the rest is not
+		json.Unmarshal(config, &c.atom)
+		if err == nil {
+			err = fmt.Errorf("config is not an object")
+		}
+		c.add("", err)
+		return nil, c.err()
+	}
+
+	json.Unmarshal(config, &c.atom)
+	c.decodeStruct(reflect.ValueOf(dst).Elem(), c.raw, "")
+
+	return c, nil
+}
+
+func (c *configCheck) decodeStruct(v reflect.Value, raw map[string]json.RawMessage, path string) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+
+		// embedded configs share the properties of the config they are in
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			c.decodeStruct(value, raw, path)
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if key, ok := lookupProperty(raw, name); ok {
+			c.decodeValue(value, raw[key], propertyPath(path, key))
+		}
+	}
+}
+
+// lookupProperty finds the key of a property the way encoding/json does,
+// preferring an exact match to a case-insensitive one.
+func lookupProperty(raw map[string]json.RawMessage, name string) (string, bool) {
+	if _, ok := raw[name]; ok {
+		return name, true
+	}
+	for key := range raw {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// decodeValue decodes a property into v. Lists of objects and nested objects
+// that are not props are decoded element by element and field by field.
+func (c *configCheck) decodeValue(v reflect.Value, raw json.RawMessage, path string) {
+	t := v.Type()
+
+	switch {
+	case t.Kind() == reflect.Struct && t.PkgPath() != propsPackage && !isNull(raw):
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &object); err != nil {
+			c.add(path, fmt.Errorf("must be an object"))
+			return
+		}
+		c.decodeStruct(v, object, path)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct && t.Elem().PkgPath() != propsPackage && !isNull(raw):
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil {
+			c.add(path, fmt.Errorf("must be a list"))
+			return
+		}
+		v.Set(reflect.MakeSlice(t, len(list), len(list)))
+		for i, element := range list {
+			c.decodeValue(v.Index(i), element, fmt.Sprintf("%s[%d]", path, i))
+		}
+	default:
+		if err := json.Unmarshal(raw, v.Addr().Interface()); err != nil {
+			c.add(path, err)
+		}
+	}
+}
+
+func isNull(raw json.RawMessage) bool {
+	return strings.TrimSpace(string(raw)) == "null"
+}
+
+func propertyPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// add records err, if any, against a property, and reports whether there was
+// no error. Errors that are already ConfigErrors keep their own properties.
+func (c *configCheck) add(property string, err error) bool {
+	switch err := err.(type) {
+	case nil:
+		return true
+	case ConfigError:
+		c.errs = append(c.errs, err)
+	case ConfigErrors:
+		c.errs = append(c.errs, err...)
+	default:
+		c.errs = append(c.errs, ConfigError{
+			BlockType: c.atom.Type,
+			Block:     c.atom.Name,
+			Property:  property,
+			Reason:    err.Error(),
+		})
+	}
+	return false
+}
+
+// addf records a reason against a property.
+func (c *configCheck) addf(property string, format string, args ...interface{}) {
+	c.add(property, fmt.Errorf(format, args...))
+}
+
+// err returns the errors collected, or nil if there are none.
+func (c *configCheck) err() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return c.errs
+}
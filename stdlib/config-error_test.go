@@ -0,0 +1,123 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigError(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.EqualError(stdlib.ConfigError{
+		BlockType: "Filter",
+		Block:     "hot",
+		Property:  "conditions[1].expr",
+		Reason:    "invalid expression",
+	}, `Filter "hot": conditions[1].expr: invalid expression`)
+
+	assert.EqualError(stdlib.ConfigError{
+		BlockType: "Filter",
+		Reason:    "config is not an object",
+	}, `Filter: config is not an object`)
+
+	assert.EqualError(stdlib.ConfigErrors{
+		{BlockType: "Filter", Property: "conditions", Reason: "no conditions"},
+		{BlockType: "Filter", Property: "operator", Reason: "invalid operation `SOME'"},
+	}, "Filter: conditions: no conditions; Filter: operator: invalid operation `SOME'")
+}
+
+func TestConfigErrors_Aggregated(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.FilterBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Filter",
+	"name": "hot",
+	"operator": "SOME",
+	"emit_order": "sideways"
+}`))
+
+	assert.Equal(stdlib.ConfigErrors{
+		{BlockType: "Filter", Block: "hot", Property: "conditions", Reason: "no conditions"},
+		{BlockType: "Filter", Block: "hot", Property: "operator", Reason: "invalid operation `SOME'"},
+		{BlockType: "Filter", Block: "hot", Property: "emit_order", Reason: "invalid emit order `sideways'"},
+	}, err)
+}
+
+func TestConfigErrors_PropertyPath(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.ModifierBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Modifier",
+	"name": "mod",
+	"fields": [
+		{"title": "ok", "formula": 1},
+		"not a field"
+	]
+}`))
+
+	if errs, ok := err.(stdlib.ConfigErrors); assert.True(ok, "%T is not ConfigErrors", err) && assert.Len(errs, 1) {
+		assert.Equal("Modifier", errs[0].BlockType)
+		assert.Equal("mod", errs[0].Block)
+		assert.Equal("fields[1]", errs[0].Property)
+		assert.Equal("must be an object", errs[0].Reason)
+	}
+
+	err = b.Configure(nio.RawBlockConfig(`{"type": "Modifier", "fields": {}}`))
+	assert.Equal(stdlib.ConfigErrors{
+		{BlockType: "Modifier", Property: "fields", Reason: "must be a list"},
+	}, err)
+}
+
+func TestConfigErrors_NotAnObject(t *testing.T) {
+	b := stdlib.NoopBlock{}
+
+	err := b.Configure(nio.RawBlockConfig(`[]`))
+
+	if errs, ok := err.(stdlib.ConfigErrors); assert.True(t, ok, "%T is not ConfigErrors", err) && assert.Len(t, errs, 1) {
+		assert.Equal(t, "", errs[0].Property)
+	}
+}
+
+func TestConfigErrors_StateExpressionUnset(t *testing.T) {
+	assert := assert.New(t)
+
+	var err error
+	assert.NotPanics(func() {
+		b := stdlib.SwitchBlock{}
+		err = b.Configure(nio.RawBlockConfig(`{"type": "Switch"}`))
+	})
+	assert.Equal(stdlib.ConfigErrors{
+		{BlockType: "Switch", Property: "state_expr", Reason: "state expression is unset"},
+	}, err)
+
+	assert.NotPanics(func() {
+		b := stdlib.AppendStateBlock{}
+		err = b.Configure(nio.RawBlockConfig(`{"type": "AppendState"}`))
+	})
+	assert.Equal(stdlib.ConfigErrors{
+		{BlockType: "AppendState", Property: "state_expr", Reason: "state expression is unset"},
+	}, err)
+}
+
+func TestConfigErrors_SimulatorProperties(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.WaveformIntervalSimulatorBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "WaveformIntervalSimulator",
+	"shape": "triangle",
+	"amplitude": "loud",
+	"period": {"seconds": 0}
+}`))
+
+	assert.Equal(stdlib.ConfigErrors{
+		{BlockType: "WaveformIntervalSimulator", Property: "shape", Reason: "invalid shape `triangle'"},
+		{BlockType: "WaveformIntervalSimulator", Property: "amplitude", Reason: "`loud' is not a number"},
+		{BlockType: "WaveformIntervalSimulator", Property: "period", Reason: "period must be positive"},
+	}, err)
+}
@@ -2,7 +2,6 @@ package stdlib
 
 import (
 	"context"
-	"encoding/json"
 	"sync"
 
 	"github.com/niolabs/gonio-framework"
@@ -12,33 +11,55 @@ import (
 type CounterBlock struct {
 	nio.Transformer
 	mixins.GroupByMixin
-	Config nio.BlockConfigAtom
+	Config CounterBlockConfig
 
-	cumulativeCount map[mixins.Group]int
-	mutex           sync.RWMutex
+	// Clock expires groups. It defaults to RealClock.
+	Clock Clock
+
+	groups groupState
+	mutex  sync.Mutex
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	metrics blockMetrics
+}
+
+type CounterBlockConfig struct {
+	nio.BlockConfigAtom
+	GroupStateConfig
 }
 
 func (b *CounterBlock) Configure(config nio.RawBlockConfig) error {
 	b.Transformer.Configure()
 
-	if err := json.Unmarshal(config, &b.Config); err != nil {
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
 		return err
 	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
 
-	if err := b.GroupByMixin.Configure(config, b.Notify); err != nil {
-		return err
-	}
+	c.add("group_by", b.GroupByMixin.Configure(config, b.metrics.notify(b.Notify)))
+
+	b.groups.configure(c, &b.Config.GroupStateConfig, b.Clock, &b.mutex, b.metrics)
+	b.groups.addGroup = b.GroupByMixin.AddGroupToSignal
 
-	b.cumulativeCount = map[mixins.Group]int{}
+	return c.err()
+}
 
-	return nil
+func (b *CounterBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	b.Transformer.EachOutput(fn)
+	b.groups.eachOutput(fn)
 }
 
 func (b *CounterBlock) Start(ctx context.Context) {
+	defer b.groups.stop()
+
 	for {
 		select {
 		case signals := <-b.ChIn:
-			b.GroupByMixin.Process(signals, b.process)
+			b.GroupByMixin.Process(signals, b.metrics.group(b.process))
 			b.Busy.Done()
 		case <-ctx.Done():
 			return
@@ -47,6 +68,7 @@ func (b *CounterBlock) Start(ctx context.Context) {
 }
 
 func (b *CounterBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
 	return b.Transformer.Enqueue(terminal, signals, 1)
 }
 
@@ -55,8 +77,10 @@ func (b *CounterBlock) process(group mixins.Group, notify nio.NotifyFunc, signal
 	defer b.mutex.Unlock()
 
 	c := len(signals)
-	next := b.cumulativeCount[group] + c
-	b.cumulativeCount[group] = next
+	prev, _ := b.groups.get(group)
+	next, _ := prev.(int)
+	next += c
+	b.groups.set(group, next)
 
 	outSignal := nio.Signal{
 		"count":            c,
@@ -66,3 +90,76 @@ func (b *CounterBlock) process(group mixins.Group, notify nio.NotifyFunc, signal
 	b.GroupByMixin.AddGroupToSignal(group, outSignal, false)
 	return notify(b.TOut, nio.SignalGroup{outSignal})
 }
+
+// Command inspects or resets the cumulative counts. counts returns the count
+// of every group; reset forgets the count of the group given, or of every
+// group, and returns the counts left.
+func (b *CounterBlock) Command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch command {
+	case "counts":
+	case "reset":
+		if group, ok := groupArg(args); ok {
+			b.groups.delete(group)
+		} else {
+			b.groups.clear()
+		}
+	default:
+		return nil, unknownCommand(command)
+	}
+
+	counts := map[string]int{}
+	b.groups.each(func(group mixins.Group, count interface{}) {
+		counts[string(group)] = count.(int)
+	})
+	return counts, nil
+}
+
+const counterVersion = "0.2.0"
+
+var Counter = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &CounterBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.counter.counter_block.Counter",
+		Version:    counterVersion,
+		Name:       "Counter",
+		Properties: groupedProperties(CounterBlockConfig{}, counterVersion),
+		Commands: map[nio.Command]nio.CommandDefinition{
+			"counts": {
+				"title":  "Counts",
+				"params": map[string]interface{}{},
+			},
+			"reset": {
+				"title": "Reset",
+				"params": map[string]interface{}{
+					"group": groupParam(true),
+				},
+			},
+		},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+				groupEvictedOutput(1),
+			},
+		},
+	},
+}
@@ -103,3 +103,44 @@ func TestCounterBlock_Grouped(t *testing.T) {
 		}, signals)
 	}
 }
+
+func TestCounterBlock_Commands(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.CounterBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Counter",
+	"group_by": "{{ $group }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a"}, nio.Signal{"group": "b"}, nio.Signal{"group": "a"})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	counts, err := b.Command("counts", nil)
+	assert.NoError(err)
+	assert.Equal(map[string]int{"a": 2, "b": 1}, counts)
+
+	counts, err = b.Command("reset", map[string]interface{}{"group": "a"})
+	assert.NoError(err)
+	assert.Equal(map[string]int{"b": 1}, counts)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a"})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "a", "count": 1, "cumulative_count": 1},
+	}, takeOne(t, b.ChOut, &b.Busy))
+
+	counts, err = b.Command("reset", nil)
+	assert.NoError(err)
+	assert.Empty(counts)
+
+	_, err = b.Command("bogus", nil)
+	assert.Error(err)
+}
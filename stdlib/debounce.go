@@ -2,7 +2,6 @@ package stdlib
 
 import (
 	"context"
-	"encoding/json"
 	"sync"
 	"time"
 
@@ -16,37 +15,54 @@ type DebounceBlock struct {
 	mixins.GroupByMixin
 	Config DebounceBlockConfig
 
-	mutex      sync.Mutex
-	lastNotify map[mixins.Group]time.Time
-	interval   time.Duration
+	// Clock times the interval. It defaults to RealClock.
+	Clock Clock
+
+	mutex    sync.Mutex
+	groups   groupState
+	interval time.Duration
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	metrics blockMetrics
 }
 
 type DebounceBlockConfig struct {
 	nio.BlockConfigAtom
-	Interval *props.TimeDeltaProperty
+	Interval *props.TimeDeltaProperty `json:"interval" order:"0" default:"{\"seconds\": 1}"`
+	GroupStateConfig
 }
 
 func (b *DebounceBlock) Configure(config nio.RawBlockConfig) error {
 	b.Transformer.Configure()
-	if err := b.GroupByMixin.Configure(config, b.Notify); err != nil {
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
 		return err
 	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
 
-	if err := json.Unmarshal(config, &b.Config); err != nil {
-		return err
-	}
+	c.add("group_by", b.GroupByMixin.Configure(config, b.metrics.notify(b.Notify)))
+	c.add("interval", b.Config.Interval.AssignDefault(&b.interval, nil, 1*time.Second))
+	b.groups.configure(c, &b.Config.GroupStateConfig, b.Clock, &b.mutex, b.metrics)
+	b.groups.addGroup = b.GroupByMixin.AddGroupToSignal
 
-	b.Config.Interval.AssignDefault(&b.interval, nil, 1*time.Second)
-	b.lastNotify = map[mixins.Group]time.Time{}
+	return c.err()
+}
 
-	return nil
+func (b *DebounceBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	b.Transformer.EachOutput(fn)
+	b.groups.eachOutput(fn)
 }
 
 func (b *DebounceBlock) Start(ctx context.Context) {
+	defer b.groups.stop()
+
 	for {
 		select {
 		case signals := <-b.ChIn:
-			b.GroupByMixin.Process(signals, b.process)
+			b.GroupByMixin.Process(signals, b.metrics.group(b.process))
 			b.Busy.Done()
 		case <-ctx.Done():
 			return
@@ -58,11 +74,11 @@ func (b *DebounceBlock) process(group mixins.Group, notify nio.NotifyFunc, signa
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
-	now := time.Now()
-	prev, hasPrev := b.lastNotify[group]
+	now := clockOrReal(b.Clock).Now()
+	prev, hasPrev := b.groups.get(group)
 
-	if !hasPrev || now.Sub(prev) > b.interval {
-		b.lastNotify[group] = now
+	if !hasPrev || now.Sub(prev.(time.Time)) > b.interval {
+		b.groups.set(group, now)
 
 		last := signals[len(signals)-1]
 		notify(b.TOut, nio.SignalGroup{last})
@@ -72,5 +88,79 @@ func (b *DebounceBlock) process(group mixins.Group, notify nio.NotifyFunc, signa
 }
 
 func (b *DebounceBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
 	return b.Transformer.Enqueue(terminal, signals, 1)
 }
+
+// Command inspects or clears the groups being debounced. groups returns when
+// every group last notified; clear forgets the group given, or every group,
+// so its next signal is notified at once, and returns the groups left.
+func (b *DebounceBlock) Command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch command {
+	case "groups":
+	case "clear":
+		if group, ok := groupArg(args); ok {
+			b.groups.delete(group)
+		} else {
+			b.groups.clear()
+		}
+	default:
+		return nil, unknownCommand(command)
+	}
+
+	groups := map[string]time.Time{}
+	b.groups.each(func(group mixins.Group, last interface{}) {
+		groups[string(group)] = last.(time.Time)
+	})
+	return groups, nil
+}
+
+const debounceVersion = "0.1.0"
+
+var Debounce = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &DebounceBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.debounce.debounce_block.Debounce",
+		Version:    debounceVersion,
+		Name:       "Debounce",
+		Properties: groupedProperties(DebounceBlockConfig{}, debounceVersion),
+		Commands: map[nio.Command]nio.CommandDefinition{
+			"groups": {
+				"title":  "Groups",
+				"params": map[string]interface{}{},
+			},
+			"clear": {
+				"title": "Clear",
+				"params": map[string]interface{}{
+					"group": groupParam(true),
+				},
+			},
+		},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+				groupEvictedOutput(1),
+			},
+		},
+	},
+}
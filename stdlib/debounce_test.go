@@ -19,7 +19,8 @@ func TestDebounceBlock_Basic(t *testing.T) {
 		<-ctx.Done()
 	}()
 
-	b := stdlib.DebounceBlock{}
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.DebounceBlock{Clock: clock}
 
 	if err := b.Configure([]byte(`{
 	"type": "Debounce",
@@ -39,12 +40,12 @@ func TestDebounceBlock_Basic(t *testing.T) {
 		}, signals)
 	}
 
-	time.Sleep(25 * time.Millisecond)
+	clock.Advance(25 * time.Millisecond)
 
 	put(t, &b, nio.DefaultTerminal, nil)
 	takeNone(t, b.ChOut, &b.Busy)
 
-	time.Sleep(30 * time.Millisecond)
+	clock.Advance(30 * time.Millisecond)
 
 	{
 		put(t, &b, nio.DefaultTerminal,
@@ -58,3 +59,78 @@ func TestDebounceBlock_Basic(t *testing.T) {
 		}, signals)
 	}
 }
+
+func TestDebounceBlock_Boundary(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.DebounceBlock{Clock: clock}
+
+	if err := b.Configure([]byte(`{
+	"type": "Debounce",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 50}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	// the interval has to have passed, not merely been reached
+	clock.Advance(50 * time.Millisecond)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{})
+	takeNone(t, b.ChOut, &b.Busy)
+
+	clock.Advance(time.Nanosecond)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{})
+	takeOne(t, b.ChOut, &b.Busy)
+}
+
+func TestDebounceBlock_Commands(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := stdlib.NewFakeClock(start)
+	b := stdlib.DebounceBlock{Clock: clock}
+
+	if err := b.Configure([]byte(`{
+	"type": "Debounce",
+	"group_by": "{{ $group }}",
+	"interval": {"seconds": 60}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a"}, nio.Signal{"group": "b"})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	groups, err := b.Command("groups", nil)
+	assert.NoError(err)
+	assert.Equal(map[string]time.Time{"a": start, "b": start}, groups)
+
+	// a cleared group is notified at once
+	groups, err = b.Command("clear", map[string]interface{}{"group": "a"})
+	assert.NoError(err)
+	assert.Equal(map[string]time.Time{"b": start}, groups)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a"}, nio.Signal{"group": "b"})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "a"},
+	}, takeOne(t, b.ChOut, &b.Busy))
+
+	groups, err = b.Command("clear", nil)
+	assert.NoError(err)
+	assert.Empty(groups)
+}
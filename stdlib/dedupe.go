@@ -0,0 +1,356 @@
+package stdlib
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/mixins"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// DedupeBlock suppresses duplicate signals, such as those of at-least-once
+// delivery. The key of a signal is the key expression if it is set, or else
+// a hash of the attributes listed, or of the whole signal if none are. A
+// signal whose key has been seen within window, among the last max_keys
+// keys of its group, is a duplicate, and is dropped or emitted on the
+// duplicate output as on_duplicate says. Seeing a key again does not extend
+// how long it is remembered, and a zero window remembers keys until newer
+// ones push them out. A signal whose key cannot be computed, including one
+// whose key expression gives null, passes through on the default output and
+// is counted as an expression error.
+//
+// As every group remembers up to max_keys keys, max_groups defaults to 1000
+// for the block rather than leaving the groups unbounded; setting it to zero
+// lifts the bound.
+type DedupeBlock struct {
+	nio.Splitter
+	mixins.GroupByMixin
+	Config DedupeBlockConfig
+
+	// Clock times the window. It defaults to RealClock.
+	Clock Clock
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	key         hoistedAny
+	hasKey      bool
+	attributes  []string
+	window      time.Duration
+	maxKeys     int64
+	onDuplicate string
+
+	mutex   sync.Mutex
+	groups  groupState
+	metrics blockMetrics
+}
+
+type DedupeBlockConfig struct {
+	nio.BlockConfigAtom
+	Key         *props.AnyProperty       `json:"key" order:"0" allow_none:"true"`
+	Attributes  props.StringPropertyList `json:"attributes" title:"Hashed Attributes" order:"1" default:"[]"`
+	Window      *props.TimeDeltaProperty `json:"window" order:"2" default:"{\"seconds\": 60}"`
+	MaxKeys     *props.IntProperty       `json:"max_keys" title:"Maximum Keys" order:"3" default:"1000"`
+	OnDuplicate *props.StringProperty    `json:"on_duplicate" title:"Duplicates" order:"4" options:"drop,duplicate" default:"drop"`
+	GroupStateConfig
+}
+
+// dedupeMaxGroups is the default max_groups of the block.
+const dedupeMaxGroups = 1000
+
+// What becomes of duplicate signals.
+const (
+	dedupeDrop = "drop"
+	dedupeEmit = "duplicate"
+)
+
+// dedupeKeys are the keys a group has seen, from the oldest to the newest.
+type dedupeKeys struct {
+	seen  map[string]*list.Element
+	order *list.List
+}
+
+type dedupeKey struct {
+	key  string
+	seen time.Time
+}
+
+func (b *DedupeBlock) Configure(config nio.RawBlockConfig) error {
+	SetTerminal(&b.TOutLeft, nio.DefaultTerminal)
+	SetTerminal(&b.TOutRight, "duplicate")
+	b.Splitter.Configure()
+
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
+
+	c.add("group_by", b.GroupByMixin.Configure(config, b.metrics.notify(b.Notify)))
+
+	b.hasKey = b.Config.Key != nil
+	if b.hasKey {
+		b.key, err = hoistAny(b.Config.Key, c.raw["key"], nil)
+		c.add("key", err)
+	}
+
+	b.attributes = make([]string, len(b.Config.Attributes))
+	for i := range b.Config.Attributes {
+		property := fmt.Sprintf("attributes[%d]", i)
+		if c.add(property, b.Config.Attributes[i].AssignToDefault(&b.attributes[i], nil, "")) && b.attributes[i] == "" {
+			c.addf(property, "attribute is empty")
+		}
+	}
+
+	if c.add("window", b.Config.Window.AssignToDefault(&b.window, nil, time.Minute)) && b.window < 0 {
+		c.addf("window", "window must not be negative")
+	}
+	if c.add("max_keys", b.Config.MaxKeys.AssignToDefault(&b.maxKeys, nil, 1000)) && b.maxKeys < 1 {
+		c.addf("max_keys", "max_keys must be at least 1")
+	}
+	if c.add("on_duplicate", b.Config.OnDuplicate.AssignToDefault(&b.onDuplicate, nil, dedupeDrop)) {
+		switch b.onDuplicate {
+		case dedupeDrop, dedupeEmit:
+		default:
+			c.addf("on_duplicate", "invalid on_duplicate `%s'", b.onDuplicate)
+		}
+	}
+
+	b.groups.defaultMaxGroups = dedupeMaxGroups
+	b.groups.configure(c, &b.Config.GroupStateConfig, b.Clock, &b.mutex, b.metrics)
+	b.groups.addGroup = b.GroupByMixin.AddGroupToSignal
+
+	return c.err()
+}
+
+func (b *DedupeBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
+	return b.Consumer.Enqueue(terminal, signals, 1)
+}
+
+func (b *DedupeBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	b.Splitter.EachOutput(fn)
+	b.groups.eachOutput(fn)
+}
+
+func (b *DedupeBlock) Start(ctx context.Context) {
+	defer b.groups.stop()
+
+	for {
+		select {
+		case signals := <-b.ChIn:
+			b.GroupByMixin.Process(signals, b.metrics.group(b.process))
+			b.Busy.Done()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *DedupeBlock) process(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error {
+	b.mutex.Lock()
+
+	now := clockOrReal(b.Clock).Now()
+
+	keys := &dedupeKeys{seen: map[string]*list.Element{}, order: list.New()}
+	if value, ok := b.groups.get(group); ok {
+		keys = value.(*dedupeKeys)
+	}
+	b.forget(keys, now)
+
+	var unique, duplicates nio.SignalGroup
+	for _, signal := range signals {
+		key, err := b.keyOf(signal)
+		if err != nil {
+			property := "key"
+			if !b.hasKey {
+				property = "attributes"
+			}
+			b.metrics.expressionError(property)
+			unique = append(unique, signal)
+			continue
+		}
+
+		if _, ok := keys.seen[key]; ok {
+			if b.onDuplicate == dedupeEmit {
+				duplicates = append(duplicates, signal)
+			}
+			continue
+		}
+
+		keys.seen[key] = keys.order.PushBack(dedupeKey{key: key, seen: now})
+		if int64(keys.order.Len()) > b.maxKeys {
+			oldest := keys.order.Remove(keys.order.Front()).(dedupeKey)
+			delete(keys.seen, oldest.key)
+		}
+		unique = append(unique, signal)
+	}
+
+	b.groups.set(group, keys)
+	b.mutex.Unlock()
+
+	if len(unique) > 0 {
+		if err := notify(b.TOutLeft, unique); err != nil {
+			return err
+		}
+	}
+	if len(duplicates) > 0 {
+		return notify(b.TOutRight, duplicates)
+	}
+	return nil
+}
+
+// keyOf returns the key of a signal: the key expression as a string, or the
+// hash of the JSON encoding of the hashed attributes, which encodes maps
+// with their keys sorted.
+func (b *DedupeBlock) keyOf(signal nio.Signal) (string, error) {
+	if b.hasKey {
+		value, err := b.key.Invoke(signal)
+		if err != nil {
+			return "", err
+		}
+		if value == nil {
+			return "", errors.New("key is null")
+		}
+		return fmt.Sprint(value), nil
+	}
+
+	hashed := map[string]interface{}(signal)
+	if len(b.attributes) > 0 {
+		hashed = make(map[string]interface{}, len(b.attributes))
+		for _, attribute := range b.attributes {
+			hashed[attribute], _ = getPath(signal, attribute)
+		}
+	}
+
+	encoded, err := json.Marshal(hashed)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// forget drops the keys seen longer than window ago, which are the oldest.
+// It must be called with the mutex held.
+func (b *DedupeBlock) forget(keys *dedupeKeys, now time.Time) {
+	if b.window == 0 {
+		return
+	}
+
+	for keys.order.Len() > 0 {
+		oldest := keys.order.Front().Value.(dedupeKey)
+		if now.Sub(oldest.seen) < b.window {
+			return
+		}
+		keys.order.Remove(keys.order.Front())
+		delete(keys.seen, oldest.key)
+	}
+}
+
+// Command inspects or clears the keys seen. keys returns when every key
+// remembered of every group was first seen; clear forgets the keys of the
+// group given, or of every group, and returns the keys left.
+func (b *DedupeBlock) Command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch command {
+	case "keys":
+	case "clear":
+		if group, ok := groupArg(args); ok {
+			b.groups.delete(group)
+		} else {
+			b.groups.clear()
+		}
+	default:
+		return nil, unknownCommand(command)
+	}
+
+	now := clockOrReal(b.Clock).Now()
+	groups := map[string]map[string]time.Time{}
+	b.groups.each(func(group mixins.Group, value interface{}) {
+		keys := value.(*dedupeKeys)
+		b.forget(keys, now)
+
+		seen := map[string]time.Time{}
+		for element := keys.order.Front(); element != nil; element = element.Next() {
+			key := element.Value.(dedupeKey)
+			seen[key.key] = key.seen
+		}
+		groups[string(group)] = seen
+	})
+	return groups, nil
+}
+
+const dedupeVersion = "0.1.0"
+
+// dedupeProperties are the properties of the block, whose max_groups has a
+// default of its own.
+func dedupeProperties() map[nio.Property]nio.PropertyDefinition {
+	properties := groupedProperties(DedupeBlockConfig{}, dedupeVersion)
+	properties["max_groups"]["default"] = float64(dedupeMaxGroups)
+	return properties
+}
+
+var Dedupe = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &DedupeBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.dedupe.dedupe_block.Dedupe",
+		Version:    dedupeVersion,
+		Name:       "Dedupe",
+		Properties: dedupeProperties(),
+		Commands: map[nio.Command]nio.CommandDefinition{
+			"keys": {
+				"title":  "Keys",
+				"params": map[string]interface{}{},
+			},
+			"clear": {
+				"title": "Clear",
+				"params": map[string]interface{}{
+					"group": groupParam(true),
+				},
+			},
+		},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+				{
+					Label:   "duplicate",
+					Type:    "output",
+					Visible: true,
+					Order:   1,
+					ID:      "duplicate",
+					Default: false,
+				},
+				groupEvictedOutput(2),
+			},
+		},
+	},
+}
@@ -0,0 +1,271 @@
+package stdlib_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupeBlock_Key(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.DedupeBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Dedupe",
+	"key": "{{ $id }}",
+	"window": {"seconds": 10}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"id": 1, "n": 1},
+		nio.Signal{"id": 2, "n": 2},
+		nio.Signal{"id": 1, "n": 3},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"id": 1, "n": 1},
+		nio.Signal{"id": 2, "n": 2},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+	takeNone(t, b.ChOutRight, &b.Busy)
+
+	// seeing a key again does not extend the window
+	clock.Advance(5 * time.Second)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"id": 2, "n": 4})
+	takeNone(t, b.ChOutLeft, &b.Busy)
+
+	clock.Advance(5 * time.Second)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"id": 2, "n": 5})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"id": 2, "n": 5},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+}
+
+func TestDedupeBlock_Hash(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.DedupeBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Dedupe",
+	"attributes": ["device", "payload.seq"],
+	"on_duplicate": "duplicate"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"device": "a", "payload": map[string]interface{}{"seq": 1}, "received": 1},
+		nio.Signal{"device": "a", "payload": map[string]interface{}{"seq": 1}, "received": 2},
+		nio.Signal{"device": "b", "payload": map[string]interface{}{"seq": 1}, "received": 3},
+		nio.Signal{"device": "a", "received": 4},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"device": "a", "payload": map[string]interface{}{"seq": 1}, "received": 1},
+		nio.Signal{"device": "b", "payload": map[string]interface{}{"seq": 1}, "received": 3},
+		nio.Signal{"device": "a", "received": 4},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"device": "a", "payload": map[string]interface{}{"seq": 1}, "received": 2},
+	}, takeOne(t, b.ChOutRight, &b.Busy))
+}
+
+func TestDedupeBlock_KeyErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := newRecordedMetrics()
+	keyed := stdlib.DedupeBlock{Metrics: metrics}
+	if err := keyed.Configure(nio.RawBlockConfig(`{
+	"type": "Dedupe",
+	"name": "keyed",
+	"key": "{{ $id }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+	hashed := stdlib.DedupeBlock{Metrics: metrics}
+	if err := hashed.Configure(nio.RawBlockConfig(`{
+	"type": "Dedupe",
+	"name": "hashed"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go keyed.Start(ctx)
+	go hashed.Start(ctx)
+
+	// signals without a key pass through rather than being taken for
+	// duplicates of each other, or vanishing
+	put(t, &keyed, nio.DefaultTerminal, nio.Signal{"n": 1}, nio.Signal{"id": nil, "n": 2})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"n": 1},
+		nio.Signal{"id": nil, "n": 2},
+	}, takeOne(t, keyed.ChOutLeft, &keyed.Busy))
+
+	nan := nio.Signal{"value": math.NaN()}
+	put(t, &hashed, nio.DefaultTerminal, nan, nan)
+	assert.Len(takeOne(t, hashed.ChOutLeft, &hashed.Busy), 2)
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	assert.Equal(map[string]int{"key": 2}, metrics.expressionErrors["keyed"])
+	assert.Equal(map[string]int{"attributes": 2}, metrics.expressionErrors["hashed"])
+}
+
+func TestDedupeBlock_MaxKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.DedupeBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Dedupe",
+	"key": "{{ $id }}",
+	"window": {"seconds": 0},
+	"max_keys": 2,
+	"group_by": "{{ $device }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"device": "a", "id": 1},
+		nio.Signal{"device": "a", "id": 2},
+		nio.Signal{"device": "b", "id": 1},
+		nio.Signal{"device": "a", "id": 3},
+		// the oldest key of the group was forgotten
+		nio.Signal{"device": "a", "id": 1},
+		nio.Signal{"device": "a", "id": 3},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"device": "a", "id": 1},
+		nio.Signal{"device": "a", "id": 2},
+		nio.Signal{"device": "a", "id": 3},
+		nio.Signal{"device": "a", "id": 1},
+		nio.Signal{"device": "b", "id": 1},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+}
+
+func TestDedupeBlock_MaxGroups(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.DedupeBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Dedupe",
+	"key": "{{ $id }}",
+	"group_by": "{{ $device }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	// the groups are bounded by default, so a group beyond the bound pushes
+	// out the least recently seen one and its keys
+	var signals nio.SignalGroup
+	for device := 0; device <= 1000; device++ {
+		signals = append(signals, nio.Signal{"device": device, "id": 1})
+	}
+	put(t, &b, nio.DefaultTerminal, signals...)
+	assert.Len(takeOne(t, b.ChOutLeft, &b.Busy), 1001)
+
+	keys, err := b.Command("keys", nil)
+	assert.NoError(err)
+	assert.Len(keys, 1000)
+	assert.NotContains(keys, "0")
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"device": 0, "id": 1}, nio.Signal{"device": 1000, "id": 1})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"device": 0, "id": 1},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+}
+
+func TestDedupeBlock_Commands(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := stdlib.NewFakeClock(start)
+	b := stdlib.DedupeBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Dedupe",
+	"key": "{{ $id }}",
+	"group_by": "{{ $group }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a", "id": 1}, nio.Signal{"group": "b", "id": 2})
+	takeOne(t, b.ChOutLeft, &b.Busy)
+
+	clock.Advance(30 * time.Second)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a", "id": 3})
+	takeOne(t, b.ChOutLeft, &b.Busy)
+
+	// keys outside the window are forgotten
+	clock.Advance(45 * time.Second)
+	keys, err := b.Command("keys", nil)
+	assert.NoError(err)
+	assert.Equal(map[string]map[string]time.Time{
+		"a": {"3": start.Add(30 * time.Second)},
+		"b": {},
+	}, keys)
+
+	keys, err = b.Command("clear", map[string]interface{}{"group": "a"})
+	assert.NoError(err)
+	assert.Equal(map[string]map[string]time.Time{"b": {}}, keys)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a", "id": 3})
+	assert.Len(takeOne(t, b.ChOutLeft, &b.Busy), 1)
+}
+
+func TestDedupeBlock_ConfigErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.DedupeBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Dedupe",
+	"max_keys": 0,
+	"on_duplicate": "keep"
+}`))
+
+	if assert.IsType(stdlib.ConfigErrors{}, err) {
+		var properties []string
+		for _, e := range err.(stdlib.ConfigErrors) {
+			properties = append(properties, e.Property)
+		}
+		assert.Equal([]string{"max_keys", "on_duplicate"}, properties)
+	}
+}
@@ -0,0 +1,263 @@
+package stdlib
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/niolabs/gonio-framework"
+)
+
+// definitionProperties builds the property definitions of a block from its
+// config struct, so the definition can't drift from what the block parses.
+// Every props field with a json name becomes a property, described by these
+// optional struct tags:
+//
+//	title       the title shown in the designer; derived from the name if unset
+//	order       the order of the property; unordered if unset
+//	default     the default, as JSON or else as a plain string
+//	advanced    "true" for properties that are hidden by default
+//	visible     "false" for properties that are never shown
+//	allow_none  "true" for properties that may be unset
+//	type        overrides the type derived from the props type
+//	options     comma separated options of a select, each either a value or
+//	            a label=value pair
+//	obj_type    the object type of nested structs
+//	definition  "-" for fields that are parsed but not advertised
+//
+// Nested structs become objects, and slices of structs become lists of
+// objects, with the fields of the struct as their template. The properties
+// common to every block, including a version defaulting to version, are
+// added as well.
+func definitionProperties(config interface{}, version string) map[nio.Property]nio.PropertyDefinition {
+	properties := commonProperties(version)
+
+	for name, definition := range structProperties(reflect.TypeOf(config)) {
+		properties[nio.Property(name)] = definition
+	}
+
+	return properties
+}
+
+// groupedProperties builds the property definitions of a block with the
+// GroupByMixin, adding the group_by property the mixin parses.
+func groupedProperties(config interface{}, version string) map[nio.Property]nio.PropertyDefinition {
+	properties := definitionProperties(config, version)
+	properties["group_by"] = nio.PropertyDefinition{
+		"order":      nil,
+		"type":       "Type",
+		"advanced":   true,
+		"visible":    true,
+		"default":    nil,
+		"allow_none": true,
+		"title":      "Group By",
+	}
+	return properties
+}
+
+// commonProperties are the properties of every block.
+func commonProperties(version string) map[nio.Property]nio.PropertyDefinition {
+	return map[nio.Property]nio.PropertyDefinition{
+		"version": {
+			"order":      nil,
+			"type":       "StringType",
+			"advanced":   true,
+			"visible":    true,
+			"default":    version,
+			"allow_none": false,
+			"title":      "Version",
+		},
+		"type": {
+			"order":      nil,
+			"advanced":   false,
+			"visible":    false,
+			"title":      "Type",
+			"type":       "StringType",
+			"readonly":   true,
+			"allow_none": false,
+			"default":    nil,
+		},
+		"id": {
+			"order":      nil,
+			"type":       "StringType",
+			"advanced":   false,
+			"visible":    false,
+			"default":    nil,
+			"allow_none": false,
+			"title":      "Id",
+		},
+		"name": {
+			"order":      nil,
+			"type":       "StringType",
+			"advanced":   false,
+			"visible":    false,
+			"default":    nil,
+			"allow_none": true,
+			"title":      "Name",
+		},
+		"log_level": {
+			"order": nil,
+			"options": map[string]int{
+				"WARNING":  30,
+				"NOTSET":   0,
+				"ERROR":    40,
+				"INFO":     20,
+				"DEBUG":    10,
+				"CRITICAL": 50,
+			},
+			"advanced":   true,
+			"visible":    true,
+			"title":      "Log Level",
+			"type":       "SelectType",
+			"enum":       "LogLevel",
+			"allow_none": false,
+			"default":    "NOTSET",
+		},
+	}
+}
+
+// propertyTypes are the designer types of the props types.
+var propertyTypes = map[string]string{
+	"AnyProperty":        "Type",
+	"StringProperty":     "StringType",
+	"BooleanProperty":    "BoolType",
+	"IntProperty":        "IntType",
+	"TimeDeltaProperty":  "TimeDeltaType",
+	"StringPropertyList": "ListType",
+}
+
+const propsPackage = "github.com/niolabs/gonio-framework/props"
+
+func structProperties(t reflect.Type) map[string]nio.PropertyDefinition {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	properties := map[string]nio.PropertyDefinition{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// embedded configs share their properties, except for the
+		// atom, whose properties are common to every block
+		if field.Anonymous {
+			if field.Type == reflect.TypeOf(nio.BlockConfigAtom{}) {
+				continue
+			}
+			for name, definition := range structProperties(field.Type) {
+				properties[name] = definition
+			}
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || field.Tag.Get("definition") == "-" {
+			continue
+		}
+
+		if definition := fieldProperty(name, field); definition != nil {
+			properties[name] = definition
+		}
+	}
+
+	return properties
+}
+
+func fieldProperty(name string, field reflect.StructField) nio.PropertyDefinition {
+	t := field.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	definition := nio.PropertyDefinition{
+		"title":      propertyTitle(name, field.Tag),
+		"order":      nil,
+		"advanced":   field.Tag.Get("advanced") == "true",
+		"visible":    field.Tag.Get("visible") != "false",
+		"allow_none": field.Tag.Get("allow_none") == "true",
+		"default":    nil,
+	}
+
+	switch {
+	case t.PkgPath() == propsPackage:
+		propertyType, ok := propertyTypes[t.Name()]
+		if !ok {
+			return nil
+		}
+		definition["type"] = propertyType
+		if propertyType == "ListType" {
+			definition["list_obj_type"] = "StringType"
+		}
+	case t.Kind() == reflect.Struct:
+		definition["type"] = "ObjectType"
+		definition["template"] = templateOf(t)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct:
+		definition["type"] = "ListType"
+		definition["list_obj_type"] = "ObjectType"
+		definition["template"] = templateOf(t.Elem())
+		definition["default"] = []interface{}{}
+	default:
+		return nil
+	}
+
+	if options := field.Tag.Get("options"); options != "" {
+		definition["type"] = "SelectType"
+		values := map[string]string{}
+		for _, option := range strings.Split(options, ",") {
+			label, value := option, option
+			if i := strings.Index(option, "="); i >= 0 {
+				label, value = option[:i], option[i+1:]
+			}
+			values[label] = value
+		}
+		definition["options"] = values
+	}
+
+	if propertyType := field.Tag.Get("type"); propertyType != "" {
+		definition["type"] = propertyType
+	}
+
+	if objType := field.Tag.Get("obj_type"); objType != "" {
+		definition["obj_type"] = objType
+	}
+
+	if order, err := strconv.Atoi(field.Tag.Get("order")); err == nil {
+		definition["order"] = order
+	}
+
+	if value, ok := field.Tag.Lookup("default"); ok {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			decoded = value
+		}
+		definition["default"] = decoded
+	}
+
+	return definition
+}
+
+// templateOf describes the properties of the objects of a nested struct.
+func templateOf(t reflect.Type) map[string]interface{} {
+	template := map[string]interface{}{}
+	for name, definition := range structProperties(t) {
+		template[name] = map[string]interface{}(definition)
+	}
+	return template
+}
+
+// propertyTitle returns the title tag of a field, or else a title made from
+// the property name, such as "Attr Name" for attr_name.
+func propertyTitle(name string, tag reflect.StructTag) string {
+	if title := tag.Get("title"); title != "" {
+		return title
+	}
+
+	words := strings.Split(name, "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,131 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+var definedBlocks = map[string]nio.BlockTypeEntry{
+	"CounterIntervalSimulator":  stdlib.CounterIntervalSimulator,
+	"IdentityIntervalSimulator": stdlib.IdentityIntervalSimulator,
+	"RandomIntervalSimulator":   stdlib.RandomIntervalSimulator,
+	"WaveformIntervalSimulator": stdlib.WaveformIntervalSimulator,
+	"ReplaySimulator":           stdlib.ReplaySimulator,
+	"Logger":                    stdlib.Logger,
+	"Modifier":                  stdlib.Modifier,
+	"Filter":                    stdlib.Filter,
+	"AttributeSelector":         stdlib.AttributeSelector,
+	"Noop":                      stdlib.Noop,
+	"Counter":                   stdlib.Counter,
+	"Debounce":                  stdlib.Debounce,
+	"Switch":                    stdlib.Switch,
+	"AppendState":               stdlib.AppendState,
+	"MergeStreams":              stdlib.MergeStreams,
+	"Delay":                     stdlib.Delay,
+	"RateLimit":                 stdlib.RateLimit,
+	"Watchdog":                  stdlib.Watchdog,
+	"Alarm":                     stdlib.Alarm,
+	"OnChange":                  stdlib.OnChange,
+	"Explode":                   stdlib.Explode,
+	"Collect":                   stdlib.Collect,
+	"Dedupe":                    stdlib.Dedupe,
+	"Sampler":                   stdlib.Sampler,
+}
+
+func TestDefinitions_Common(t *testing.T) {
+	for name, entry := range definedBlocks {
+		definition := entry.Definition
+		assert.Equal(t, name, definition.Name)
+		assert.Equal(t, definition.Version, definition.Properties["version"]["default"],
+			"%s advertises a version other than its own", name)
+
+		for _, property := range []nio.Property{"id", "name", "type", "version", "log_level"} {
+			assert.Contains(t, definition.Properties, property, "%s lacks %s", name, property)
+		}
+	}
+}
+
+func TestDefinitions_CounterIntervalSimulator(t *testing.T) {
+	assert := assert.New(t)
+
+	properties := stdlib.CounterIntervalSimulator.Definition.Properties
+
+	assert.NotContains(properties, nio.Property("limit"), "the deprecated limit is not advertised")
+	assert.Equal("IntType", properties["total_signals"]["type"])
+	assert.EqualValues(-1, properties["total_signals"]["default"])
+	assert.Equal(map[string]interface{}{"seconds": float64(1)}, properties["interval"]["default"])
+	assert.Equal(true, properties["jitter"]["advanced"])
+
+	assert.Equal("sim", properties["attr_name"]["default"])
+	assert.Equal("Attribute Name", properties["attr_name"]["title"])
+	assert.Equal(1, properties["attr_name"]["order"])
+
+	assert.Equal("ObjectType", properties["attr_value"]["type"])
+	template := properties["attr_value"]["template"].(map[string]interface{})
+	assert.Len(template, 3)
+	assert.EqualValues(1, template["step"].(map[string]interface{})["default"])
+}
+
+func TestDefinitions_Modifier(t *testing.T) {
+	assert := assert.New(t)
+
+	fields := stdlib.Modifier.Definition.Properties["fields"]
+	assert.Equal("ListType", fields["type"])
+	assert.Equal("ObjectType", fields["list_obj_type"])
+	assert.Equal("SignalField", fields["obj_type"])
+
+	action := fields["template"].(map[string]interface{})["action"].(map[string]interface{})
+	assert.Equal("SelectType", action["type"])
+	assert.Equal(map[string]string{"set": "set", "delete": "delete"}, action["options"])
+	assert.Equal("set", action["default"])
+}
+
+func TestDefinitions_Filter(t *testing.T) {
+	assert := assert.New(t)
+
+	properties := stdlib.Filter.Definition.Properties
+
+	conditions := properties["conditions"]
+	assert.Equal("ListType", conditions["type"])
+	assert.Equal("Condition", conditions["obj_type"])
+	expr := conditions["template"].(map[string]interface{})["expr"].(map[string]interface{})
+	assert.Equal("BoolType", expr["type"])
+
+	assert.Equal("SelectType", properties["operator"]["type"])
+	assert.Len(properties["operator"]["options"], 4)
+	assert.Equal("ALL", properties["operator"]["default"])
+
+	outputs := stdlib.Filter.Definition.BlockAttributes.Outputs
+	assert.Equal("true", outputs[0].ID)
+	assert.Equal("false", outputs[1].ID)
+}
+
+func TestDefinitions_Logger(t *testing.T) {
+	// the logger has no properties of its own
+	assert.Len(t, stdlib.Logger.Definition.Properties, 5)
+}
+
+func TestDefinitions_Grouped(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, entry := range []nio.BlockTypeEntry{stdlib.Counter, stdlib.Debounce, stdlib.Switch, stdlib.AppendState, stdlib.MergeStreams} {
+		definition := entry.Definition
+		for _, property := range []nio.Property{"group_by", "group_ttl", "max_groups", "emit_evicted"} {
+			assert.Contains(definition.Properties, property, "%s lacks %s", definition.Name, property)
+		}
+		assert.NotEmpty(definition.Commands, "%s has no commands", definition.Name)
+
+		outputs := definition.BlockAttributes.Outputs
+		assert.Equal("evicted", outputs[len(outputs)-1].ID, "%s lacks the evicted output", definition.Name)
+	}
+
+	assert.EqualValues(0, stdlib.Counter.Definition.Properties["max_groups"]["default"])
+	assert.EqualValues(1000, stdlib.Dedupe.Definition.Properties["max_groups"]["default"])
+
+	setState := stdlib.Switch.Definition.Commands["set_state"]["params"].(map[string]interface{})
+	assert.Equal(false, setState["group"].(map[string]interface{})["allow_none"])
+	assert.Equal("BoolType", setState["state"].(map[string]interface{})["type"])
+}
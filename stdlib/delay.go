@@ -0,0 +1,330 @@
+package stdlib
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/mixins"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// DelayBlock re-emits signals once their delay has passed. The delay may be
+// an expression evaluated for every signal, yet signals of a group are still
+// emitted in the order they arrived: a signal is held at least until those
+// of its group that arrived before it are due. Signals on the cancel input
+// cancel the signals pending for their groups. When the block stops, the
+// pending signals are dropped, or flushed if on_stop says so; a flush that
+// finds the output full is dropped too, and counted as dropped.
+type DelayBlock struct {
+	nio.Joiner
+	mixins.GroupByMixin
+	Config DelayBlockConfig
+
+	// Clock times the delays. It defaults to RealClock.
+	Clock Clock
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	delay   hoistedDuration
+	onStop  string
+	mutex   sync.Mutex
+	pending map[mixins.Group]*delayQueue
+	outbox  outbox
+	metrics blockMetrics
+}
+
+type DelayBlockConfig struct {
+	nio.BlockConfigAtom
+	Delay  *props.TimeDeltaProperty `json:"delay" order:"0" default:"{\"seconds\": 1}"`
+	OnStop *props.StringProperty    `json:"on_stop" title:"Pending Signals On Stop" order:"1" advanced:"true" options:"drop,flush" default:"drop"`
+}
+
+// What becomes of pending signals when the block stops.
+const (
+	delayOnStopDrop  = "drop"
+	delayOnStopFlush = "flush"
+)
+
+// delayQueue holds the pending signals of a group, in the order they are
+// due, and the timer of the first.
+type delayQueue struct {
+	signals []delayedSignal
+	timer   Timer
+}
+
+type delayedSignal struct {
+	signal nio.Signal
+	due    time.Time
+}
+
+func (b *DelayBlock) Configure(config nio.RawBlockConfig) error {
+	SetTerminal(&b.TInLeft, nio.DefaultTerminal)
+	SetTerminal(&b.TInRight, "cancel")
+	b.Joiner.Configure()
+
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
+
+	c.add("group_by", b.GroupByMixin.Configure(config, b.metrics.notify(b.Notify)))
+
+	b.delay, err = hoistDuration(b.Config.Delay, c.raw["delay"], time.Second)
+	c.add("delay", err)
+
+	if c.add("on_stop", b.Config.OnStop.AssignToDefault(&b.onStop, nil, delayOnStopDrop)) &&
+		b.onStop != delayOnStopDrop && b.onStop != delayOnStopFlush {
+		c.addf("on_stop", "`%s' is neither %s nor %s", b.onStop, delayOnStopDrop, delayOnStopFlush)
+	}
+
+	b.pending = map[mixins.Group]*delayQueue{}
+	b.outbox.configure(b.metrics)
+
+	return c.err()
+}
+
+func (b *DelayBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
+	return b.Joiner.Enqueue(terminal, signals, 1)
+}
+
+func (b *DelayBlock) Start(ctx context.Context) {
+	defer b.stop()
+
+	for {
+		select {
+		case signals := <-b.ChInLeft:
+			b.GroupByMixin.Process(signals, b.metrics.group(b.processDelay))
+			b.Busy.Done()
+		case signals := <-b.ChInRight:
+			b.GroupByMixin.Process(signals, b.metrics.group(b.processCancel))
+			b.Busy.Done()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *DelayBlock) processDelay(group mixins.Group, _ nio.NotifyFunc, signals nio.SignalGroup) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := clockOrReal(b.Clock).Now()
+
+	queue, ok := b.pending[group]
+	if !ok {
+		queue = &delayQueue{}
+	}
+
+	for _, signal := range signals {
+		delay, err := b.delay.Invoke(signal)
+		if err != nil {
+			b.metrics.expressionError("delay")
+			continue
+		}
+
+		due := now.Add(delay)
+		if n := len(queue.signals); n > 0 && due.Before(queue.signals[n-1].due) {
+			due = queue.signals[n-1].due
+		}
+		queue.signals = append(queue.signals, delayedSignal{signal: signal, due: due})
+	}
+
+	if len(queue.signals) > 0 && !ok {
+		b.pending[group] = queue
+		b.metrics.stateSize(len(b.pending))
+	}
+	b.schedule(group, queue)
+
+	return nil
+}
+
+func (b *DelayBlock) processCancel(group mixins.Group, _ nio.NotifyFunc, _ nio.SignalGroup) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.cancel(group)
+	return nil
+}
+
+// schedule arms the timer of a queue for its first signal, unless it is
+// armed already. It must be called with the mutex held.
+func (b *DelayBlock) schedule(group mixins.Group, queue *delayQueue) {
+	if queue.timer != nil || len(queue.signals) == 0 {
+		return
+	}
+
+	clock := clockOrReal(b.Clock)
+	queue.timer = clock.AfterFunc(queue.signals[0].due.Sub(clock.Now()), func() { b.emit(group, queue) })
+}
+
+// emit emits the signals of a queue that are due. They are added to the
+// outbox with the mutex held, so the signals of a group are emitted in order
+// even when its timer fires again before they are sent.
+func (b *DelayBlock) emit(group mixins.Group, queue *delayQueue) {
+	defer b.outbox.flush()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// the queue was cancelled or the block stopped
+	if b.pending[group] != queue {
+		return
+	}
+	queue.timer = nil
+
+	now := clockOrReal(b.Clock).Now()
+
+	var due nio.SignalGroup
+	for len(queue.signals) > 0 && !queue.signals[0].due.After(now) {
+		due = append(due, queue.signals[0].signal)
+		queue.signals = queue.signals[1:]
+	}
+
+	if len(queue.signals) == 0 {
+		delete(b.pending, group)
+		b.metrics.stateSize(len(b.pending))
+	} else {
+		b.schedule(group, queue)
+	}
+
+	if len(due) > 0 {
+		b.outbox.add(b.TOut, b.ChOut, due)
+	}
+}
+
+// cancel drops the pending signals of a group and returns how many there
+// were. It must be called with the mutex held.
+func (b *DelayBlock) cancel(group mixins.Group) int {
+	queue, ok := b.pending[group]
+	if !ok {
+		return 0
+	}
+
+	if queue.timer != nil {
+		queue.timer.Stop()
+	}
+	delete(b.pending, group)
+	b.metrics.stateSize(len(b.pending))
+
+	return len(queue.signals)
+}
+
+// stop disarms every timer when the block stops, emitting the pending
+// signals at once, in the order they were due, if on_stop is flush. Nothing
+// reads the output for certain once the block stops, so the flush is only
+// sent if the output has room.
+func (b *DelayBlock) stop() {
+	b.outbox.close()
+	defer b.outbox.flush()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var pending []delayedSignal
+	for group, queue := range b.pending {
+		pending = append(pending, queue.signals...)
+		b.cancel(group)
+	}
+
+	if b.onStop != delayOnStopFlush || len(pending) == 0 {
+		return
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].due.Before(pending[j].due)
+	})
+
+	flushed := make(nio.SignalGroup, len(pending))
+	for i, delayed := range pending {
+		flushed[i] = delayed.signal
+	}
+	b.outbox.add(b.TOut, b.ChOut, flushed)
+}
+
+// Command inspects or cancels the pending signals. pending returns the
+// number of signals pending for every group; cancel drops those of the group
+// given, or of every group, and returns how many were dropped.
+func (b *DelayBlock) Command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch command {
+	case "pending":
+		pending := map[string]int{}
+		for group, queue := range b.pending {
+			pending[string(group)] = len(queue.signals)
+		}
+		return pending, nil
+	case "cancel":
+		cancelled := 0
+		if group, ok := groupArg(args); ok {
+			cancelled = b.cancel(group)
+		} else {
+			for group := range b.pending {
+				cancelled += b.cancel(group)
+			}
+		}
+		return map[string]interface{}{"cancelled": cancelled}, nil
+	default:
+		return nil, unknownCommand(command)
+	}
+}
+
+const delayVersion = "0.1.0"
+
+var Delay = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &DelayBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.delay.delay_block.Delay",
+		Version:    delayVersion,
+		Name:       "Delay",
+		Properties: groupedProperties(DelayBlockConfig{}, delayVersion),
+		Commands: map[nio.Command]nio.CommandDefinition{
+			"pending": {
+				"title":  "Pending",
+				"params": map[string]interface{}{},
+			},
+			"cancel": {
+				"title": "Cancel",
+				"params": map[string]interface{}{
+					"group": groupParam(true),
+				},
+			},
+		},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+				{
+					Label:   "cancel",
+					Type:    "input",
+					Visible: true,
+					Order:   1,
+					ID:      "cancel",
+					Default: false,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+		},
+	},
+}
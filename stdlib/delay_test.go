@@ -0,0 +1,257 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelayBlock_Basic(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.DelayBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Delay",
+	"delay": {"seconds": 30}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"relay": "on"}, nio.Signal{"relay": "off"})
+	takeNone(t, b.ChOut, &b.Busy)
+
+	clock.Advance(29 * time.Second)
+	takeNone(t, b.ChOut, &b.Busy)
+
+	clock.Advance(time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"relay": "on"},
+		nio.Signal{"relay": "off"},
+	}, takeOne(t, b.ChOut, &b.Busy))
+	takeNone(t, b.ChOut, &b.Busy)
+}
+
+func TestDelayBlock_Expression(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.DelayBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Delay",
+	"delay": "{{ $wait }}",
+	"group_by": "{{ $group }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"group": "a", "n": 1, "wait": 20},
+		nio.Signal{"group": "b", "n": 2, "wait": 5},
+		// held until the first signal of a is due, to keep a in order
+		nio.Signal{"group": "a", "n": 3, "wait": 10},
+	)
+	takeNone(t, b.ChOut, &b.Busy)
+
+	clock.Advance(5 * time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "b", "n": 2, "wait": 5},
+	}, takeOne(t, b.ChOut, &b.Busy))
+
+	clock.Advance(10 * time.Second)
+	takeNone(t, b.ChOut, &b.Busy)
+
+	clock.Advance(5 * time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "a", "n": 1, "wait": 20},
+		nio.Signal{"group": "a", "n": 3, "wait": 10},
+	}, takeOne(t, b.ChOut, &b.Busy))
+}
+
+func TestDelayBlock_Cancel(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.DelayBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Delay",
+	"delay": {"seconds": 30},
+	"group_by": "{{ $relay }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"relay": 1}, nio.Signal{"relay": 2}, nio.Signal{"relay": 3})
+	takeNone(t, b.ChOut, &b.Busy)
+
+	pending, err := b.Command("pending", nil)
+	assert.NoError(err)
+	assert.Equal(map[string]int{"1": 1, "2": 1, "3": 1}, pending)
+
+	put(t, &b, "cancel", nio.Signal{"relay": 1})
+	b.Busy.Wait()
+
+	cancelled, err := b.Command("cancel", map[string]interface{}{"group": 2})
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{"cancelled": 1}, cancelled)
+
+	clock.Advance(30 * time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"relay": 3},
+	}, takeOne(t, b.ChOut, &b.Busy))
+	takeNone(t, b.ChOut, &b.Busy)
+
+	_, err = b.Command("bogus", nil)
+	assert.Error(err)
+}
+
+func TestDelayBlock_OnStop(t *testing.T) {
+	for onStop, flushed := range map[string]bool{"drop": false, "flush": true} {
+		t.Run(onStop, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+
+			clock := stdlib.NewFakeClock(time.Now())
+			b := stdlib.DelayBlock{Clock: clock}
+
+			if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Delay",
+	"delay": "{{ $wait }}",
+	"group_by": "{{ $group }}",
+	"on_stop": "` + onStop + `"
+}`)); err != nil {
+				t.Fatal(err)
+			}
+
+			stopped := make(chan struct{})
+			go func() {
+				b.Start(ctx)
+				close(stopped)
+			}()
+
+			put(t, &b, nio.DefaultTerminal,
+				nio.Signal{"group": "a", "wait": 20},
+				nio.Signal{"group": "b", "wait": 10},
+			)
+			b.Busy.Wait()
+
+			cancel()
+			<-stopped
+
+			if flushed {
+				assert.EqualValues(t, nio.SignalGroup{
+					nio.Signal{"group": "b", "wait": 10},
+					nio.Signal{"group": "a", "wait": 20},
+				}, takeOne(t, b.ChOut, &b.Busy))
+			} else {
+				takeNone(t, b.ChOut, &b.Busy)
+			}
+
+			// timers are disarmed
+			clock.Advance(time.Minute)
+			takeNone(t, b.ChOut, &b.Busy)
+		})
+	}
+}
+
+func TestDelayBlock_OutputFull(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	metrics := newRecordedMetrics()
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.DelayBlock{Clock: clock, Metrics: metrics}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Delay",
+	"delay": "{{ $wait }}",
+	"group_by": "{{ $group }}",
+	"on_stop": "flush"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		b.Start(ctx)
+		close(stopped)
+	}()
+
+	// nothing reads the output
+	for len(b.ChOut) < cap(b.ChOut) {
+		b.ChOut <- nio.SignalGroup{}
+	}
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"group": "a", "wait": 10},
+		nio.Signal{"group": "b", "wait": 20},
+	)
+	b.Busy.Wait()
+
+	// a is due, and waits for the output without holding up the block
+	advanced := make(chan struct{})
+	go func() {
+		clock.Advance(10 * time.Second)
+		close(advanced)
+	}()
+
+	deadline := time.Now().Add(hangGuard)
+	for {
+		pending, err := b.Command("pending", nil)
+		assert.NoError(err)
+		if len(pending.(map[string]int)) == 1 {
+			assert.Equal(map[string]int{"b": 1}, pending)
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("a is still pending")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// stopping gives up on a, and on flushing b
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(hangGuard):
+		t.Fatal("the block did not stop")
+	}
+	<-advanced
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	assert.Equal(2, metrics.signalsDropped["Delay"]["stopped"])
+}
+
+func TestDelayBlock_ConfigErrors(t *testing.T) {
+	b := stdlib.DelayBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Delay",
+	"name": "relay",
+	"on_stop": "keep"
+}`))
+
+	assert.EqualError(t, err, "Delay \"relay\": on_stop: `keep' is neither drop nor flush")
+}
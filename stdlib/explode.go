@@ -0,0 +1,182 @@
+package stdlib
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// ExplodeBlock reshapes signals from other systems. When attribute is set,
+// a signal whose attribute is a list becomes one signal per element, each a
+// copy of the signal with the element in place of the list, and with its
+// index under index_attr if that is set. Signals without the attribute, or
+// whose attribute is not a list, pass unchanged. The attribute may be a
+// dotted path such as "payload.readings".
+//
+// When flatten is set, nested objects of the signals, exploded or not, are
+// flattened into attributes whose names join the path to each value with
+// separator, so {"a": {"b": {"c": 1}}} becomes {"a_b_c": 1}.
+type ExplodeBlock struct {
+	nio.Transformer
+	Config ExplodeBlockConfig
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	attribute string
+	indexAttr string
+	flatten   bool
+	separator string
+
+	metrics blockMetrics
+}
+
+type ExplodeBlockConfig struct {
+	nio.BlockConfigAtom
+	Attribute *props.StringProperty  `json:"attribute" title:"List Attribute" order:"0" default:""`
+	IndexAttr *props.StringProperty  `json:"index_attr" title:"Index Attribute" order:"1" default:""`
+	Flatten   *props.BooleanProperty `json:"flatten" title:"Flatten Nested Objects?" order:"2" default:"false"`
+	Separator *props.StringProperty  `json:"separator" order:"3" advanced:"true" default:"_"`
+}
+
+func (b *ExplodeBlock) Configure(config nio.RawBlockConfig) error {
+	b.Transformer.Configure()
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
+
+	c.add("attribute", b.Config.Attribute.AssignToDefault(&b.attribute, nil, ""))
+	c.add("index_attr", b.Config.IndexAttr.AssignToDefault(&b.indexAttr, nil, ""))
+	c.add("flatten", b.Config.Flatten.AssignToDefault(&b.flatten, nil, false))
+	c.add("separator", b.Config.Separator.AssignToDefault(&b.separator, nil, "_"))
+
+	if b.attribute == "" && !b.flatten {
+		c.addf("", "neither attribute nor flatten is set")
+	}
+
+	return c.err()
+}
+
+func (b *ExplodeBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
+	return b.Transformer.Enqueue(terminal, signals, 1)
+}
+
+func (b *ExplodeBlock) Start(ctx context.Context) {
+	for {
+		select {
+		case inSignals := <-b.ChIn:
+			start := time.Now()
+			var outSignals nio.SignalGroup
+
+			for _, inSignal := range inSignals {
+				for _, outSignal := range b.explode(inSignal) {
+					if b.flatten {
+						outSignal = flattenSignal(outSignal, b.separator)
+					}
+					outSignals = append(outSignals, outSignal)
+				}
+			}
+
+			b.metrics.processed(start)
+			if len(outSignals) > 0 {
+				b.metrics.out(b.TOut, outSignals)
+				b.ChOut <- outSignals
+			}
+			b.Busy.Done()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// explode returns a signal for each element of the list attribute of a
+// signal, or the signal itself if it has no list to explode.
+func (b *ExplodeBlock) explode(signal nio.Signal) nio.SignalGroup {
+	if b.attribute == "" {
+		return nio.SignalGroup{signal}
+	}
+
+	value, ok := getPath(signal, b.attribute)
+	if !ok || value == nil {
+		return nio.SignalGroup{signal}
+	}
+	list := reflect.ValueOf(value)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return nio.SignalGroup{signal}
+	}
+
+	signals := make(nio.SignalGroup, list.Len())
+	for i := range signals {
+		element := signal.Clone()
+		setPath(element, b.attribute, list.Index(i).Interface())
+		if b.indexAttr != "" {
+			element[b.indexAttr] = i
+		}
+		signals[i] = element
+	}
+	return signals
+}
+
+// flattenSignal returns a copy of a signal with its nested objects replaced
+// by attributes named by their path, joined with separator. Empty objects
+// are kept as they are, so no attribute is lost.
+func flattenSignal(signal nio.Signal, separator string) nio.Signal {
+	flat := nio.Signal{}
+	flattenInto(flat, "", signal, separator)
+	return flat
+}
+
+func flattenInto(flat nio.Signal, prefix string, object map[string]interface{}, separator string) {
+	for key, value := range object {
+		if prefix != "" {
+			key = prefix + separator + key
+		}
+		if nested, ok := asMap(value); ok && len(nested) > 0 {
+			flattenInto(flat, key, nested, separator)
+			continue
+		}
+		flat[key] = value
+	}
+}
+
+const explodeVersion = "0.1.0"
+
+var Explode = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &ExplodeBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.explode.explode_block.Explode",
+		Version:    explodeVersion,
+		Name:       "Explode",
+		Properties: definitionProperties(ExplodeBlockConfig{}, explodeVersion),
+		Commands:   map[nio.Command]nio.CommandDefinition{},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+		},
+	},
+}
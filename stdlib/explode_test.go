@@ -0,0 +1,115 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplodeBlock_Basic(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.ExplodeBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Explode",
+	"attribute": "payload.readings",
+	"index_attr": "index"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	payload := map[string]interface{}{"unit": "C", "readings": []interface{}{20.5, 21.0}}
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"device": "a", "payload": payload},
+		nio.Signal{"device": "b", "payload": map[string]interface{}{"readings": []float64{}}},
+		// no list to explode
+		nio.Signal{"device": "c", "payload": map[string]interface{}{"readings": 19.0}},
+		nio.Signal{"device": "d"},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"device": "a", "index": 0, "payload": map[string]interface{}{"unit": "C", "readings": 20.5}},
+		nio.Signal{"device": "a", "index": 1, "payload": map[string]interface{}{"unit": "C", "readings": 21.0}},
+		nio.Signal{"device": "c", "payload": map[string]interface{}{"readings": 19.0}},
+		nio.Signal{"device": "d"},
+	}, takeOne(t, b.ChOut, &b.Busy))
+
+	// the incoming signal is left untouched
+	assert.Equal([]interface{}{20.5, 21.0}, payload["readings"])
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"payload": map[string]interface{}{"readings": []interface{}{}}})
+	takeNone(t, b.ChOut, &b.Busy)
+}
+
+func TestExplodeBlock_Flatten(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.ExplodeBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Explode",
+	"attribute": "readings",
+	"flatten": true
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{
+		"device": map[string]interface{}{"id": "a", "location": map[string]interface{}{"floor": 2}},
+		"readings": []interface{}{
+			map[string]interface{}{"value": 20.5, "tags": map[string]interface{}{}},
+			map[string]interface{}{"value": 21.0, "tags": []interface{}{"calibrated"}},
+		},
+	})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"device_id": "a", "device_location_floor": 2, "readings_value": 20.5, "readings_tags": map[string]interface{}{}},
+		nio.Signal{"device_id": "a", "device_location_floor": 2, "readings_value": 21.0, "readings_tags": []interface{}{"calibrated"}},
+	}, takeOne(t, b.ChOut, &b.Busy))
+}
+
+func TestExplodeBlock_FlattenOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.ExplodeBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Explode",
+	"flatten": true,
+	"separator": "."
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}}, "d": []interface{}{1, 2}})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"a.b.c": 1, "d": []interface{}{1, 2}},
+	}, takeOne(t, b.ChOut, &b.Busy))
+}
+
+func TestExplodeBlock_ConfigErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.ExplodeBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Explode"
+}`))
+	assert.EqualError(err, "Explode: neither attribute nor flatten is set")
+}
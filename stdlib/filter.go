@@ -2,59 +2,115 @@ package stdlib
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
+	"time"
 
 	"github.com/niolabs/gonio-framework"
 	"github.com/niolabs/gonio-framework/props"
 )
 
+// FilterBlock splits signals by their conditions, combined by the operator,
+// into those that pass on the true output and the rest on the false output.
+// A signal any of whose conditions fails to evaluate is neither: it is
+// dropped and counted as an expression error of the condition, whatever
+// emit_order and emit_empty say. The index_attr still counts it, so indexes
+// stay those of the incoming signal group.
 type FilterBlock struct {
 	nio.Splitter
 	Config FilterBlockConfig
 
-	operator string
+	operator   filterOperator
+	conditions []hoistedBool
+	falseFirst bool
+	emitEmpty  bool
+	indexAttr  string
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	metrics blockMetrics
 }
 
 type FilterBlockConfig struct {
 	nio.BlockConfigAtom
-	Operator   *props.StringProperty `json:"operator"`
+	Operator   *props.StringProperty `json:"operator" title:"Condition Operator" order:"1" options:"ALL,ANY,NONE,XOR" default:"ALL"`
 	Conditions []struct {
-		Expr props.BooleanProperty `json:"expr"`
-	} `json:"conditions"`
+		Expr props.BooleanProperty `json:"expr" title:"Condition" order:"0"`
+	} `json:"conditions" title:"Filter Conditions" order:"0" obj_type:"Condition"`
+	EmitOrder *props.StringProperty  `json:"emit_order" title:"Emit Order" order:"2" advanced:"true" options:"true_first,false_first" default:"true_first"`
+	EmitEmpty *props.BooleanProperty `json:"emit_empty" title:"Emit Empty Groups?" order:"3" advanced:"true" default:"false"`
+	IndexAttr *props.StringProperty  `json:"index_attr" title:"Index Attribute" order:"4" advanced:"true" default:""`
 }
 
+// filterOperator combines the results of a signal's conditions, given how
+// many of them held out of the total.
+type filterOperator func(passed, total int) bool
+
+var filterOperators = map[string]filterOperator{
+	"ALL":  func(passed, total int) bool { return passed == total },
+	"ANY":  func(passed, total int) bool { return passed > 0 },
+	"NONE": func(passed, total int) bool { return passed == 0 },
+	// XOR holds when an odd number of conditions pass
+	"XOR": func(passed, total int) bool { return passed%2 == 1 },
+}
+
+const (
+	filterTrueFirst  = "true_first"
+	filterFalseFirst = "false_first"
+)
+
 func (fb *FilterBlock) Configure(config nio.RawBlockConfig) error {
 	SetTerminal(&fb.TOutLeft, "true")
 	SetTerminal(&fb.TOutRight, "false")
 
 	fb.Splitter.Configure()
 
-	if err := json.Unmarshal(config, &fb.Config); err != nil {
+	c, err := decodeConfig(config, &fb.Config)
+	if err != nil {
 		return err
 	}
+	fb.metrics = newBlockMetrics(fb.Metrics, c.atom)
 
 	if len(fb.Config.Conditions) == 0 {
-		return errors.New("configuration error: no conditions")
+		c.addf("conditions", "no conditions")
+	}
+
+	rawConditions, err := rawPropertyList(c.raw["conditions"])
+	if err != nil {
+		// decodeConfig has reported the malformed list already
+		return c.err()
+	}
+
+	fb.conditions = make([]hoistedBool, len(fb.Config.Conditions))
+	for i := range fb.Config.Conditions {
+		fb.conditions[i], err = hoistBool(&fb.Config.Conditions[i].Expr, rawConditions[i]["expr"], false)
+		c.add(fmt.Sprintf("conditions[%d].expr", i), err)
+	}
+
+	var op string
+	if c.add("operator", fb.Config.Operator.AssignToDefault(&op, nil, "ALL")) {
+		if fb.operator = filterOperators[op]; fb.operator == nil {
+			c.addf("operator", "invalid operation `%s'", op)
+		}
 	}
 
-	if prop := fb.Config.Operator; prop == nil {
-		fb.operator = "ALL"
-	} else {
-		switch op, err := prop.Invoke(nil); {
-		case err != nil:
-			return err
-		case op == "ALL":
-			fb.operator = "ALL"
-		case op == "ANY":
-			fb.operator = "ANY"
+	var order string
+	if c.add("emit_order", fb.Config.EmitOrder.AssignToDefault(&order, nil, filterTrueFirst)) {
+		switch order {
+		case filterTrueFirst:
+			fb.falseFirst = false
+		case filterFalseFirst:
+			fb.falseFirst = true
 		default:
-			return fmt.Errorf("configuration error: invalid operation `%s'", op)
+			c.addf("emit_order", "invalid emit order `%s'", order)
 		}
 	}
 
-	return nil
+	c.add("emit_empty", fb.Config.EmitEmpty.AssignToDefault(&fb.emitEmpty, nil, false))
+	c.add("index_attr", fb.Config.IndexAttr.AssignToDefault(&fb.indexAttr, nil, ""))
+
+	return c.err()
 }
 
 func (fb *FilterBlock) Start(ctx context.Context) {
@@ -70,59 +126,104 @@ func (fb *FilterBlock) Start(ctx context.Context) {
 }
 
 func (fb *FilterBlock) Enqueue(t nio.Terminal, signals nio.SignalGroup) error {
+	fb.metrics.in(t, signals)
 	return fb.Consumer.Enqueue(t, signals, 1)
 }
 
 func (fb *FilterBlock) process(signals nio.SignalGroup) {
+	start := time.Now()
 	total := len(signals)
 
 	trueSignals := make(nio.SignalGroup, 0, total)
 	falseSignals := make(nio.SignalGroup, 0, total)
 
 SignalLoop:
-	for _, signal := range signals {
-		var test bool
-
-		switch fb.operator {
-		case "ALL":
-			test = true
-		case "ANY":
-			test = false
-		default:
-			// TODO handle invalid operator
-			panic("invalid operator")
-		}
-
-		for _, prop := range fb.Config.Conditions {
-			b, err := prop.Expr.Invoke(signal)
+	for i, signal := range signals {
+		passed := 0
+		for j := range fb.conditions {
+			b, err := fb.conditions[j].Invoke(signal)
 			if err != nil {
-				// TODO handle expr eval error
+				fb.metrics.expressionError(fmt.Sprintf("conditions[%d].expr", j))
 				continue SignalLoop
 			}
-
-			switch fb.operator {
-			case "ALL":
-				test = test && b
-			case "ANY":
-				test = test || b
+			if b {
+				passed++
 			}
 		}
 
-		switch test {
-		case true:
+		if fb.indexAttr != "" {
+			signal = signal.Clone()
+			signal[fb.indexAttr] = i
+		}
+
+		if fb.operator(passed, len(fb.conditions)) {
 			trueSignals = append(trueSignals, signal)
-		case false:
+		} else {
 			falseSignals = append(falseSignals, signal)
 		}
 	}
 
-	// notify in non-deterministic order
-	for ch, outSignals := range map[chan nio.SignalGroup]nio.SignalGroup{
-		fb.ChOutLeft:  trueSignals,
-		fb.ChOutRight: falseSignals,
-	} {
-		if len(outSignals) > 0 && ch != nil {
-			ch <- outSignals
+	fb.metrics.processed(start)
+
+	outputs := [2]struct {
+		terminal nio.Terminal
+		ch       chan nio.SignalGroup
+		signals  nio.SignalGroup
+	}{
+		{fb.TOutLeft, fb.ChOutLeft, trueSignals},
+		{fb.TOutRight, fb.ChOutRight, falseSignals},
+	}
+	if fb.falseFirst {
+		outputs[0], outputs[1] = outputs[1], outputs[0]
+	}
+
+	for _, out := range outputs {
+		if (len(out.signals) > 0 || fb.emitEmpty) && out.ch != nil {
+			fb.metrics.out(out.terminal, out.signals)
+			out.ch <- out.signals
 		}
 	}
 }
+
+const filterVersion = "0.1.0"
+
+var Filter = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &FilterBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.filter.filter_block.Filter",
+		Version:    filterVersion,
+		Name:       "Filter",
+		Properties: definitionProperties(FilterBlockConfig{}, filterVersion),
+		Commands:   map[nio.Command]nio.CommandDefinition{},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "true",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "true",
+					Default: true,
+				},
+				{
+					Label:   "false",
+					Type:    "output",
+					Visible: true,
+					Order:   1,
+					ID:      "false",
+					Default: false,
+				},
+			},
+		},
+	},
+}
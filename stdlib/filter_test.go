@@ -165,3 +165,165 @@ func TestFilterBlock_Dynamic(t *testing.T) {
 	default:
 	}
 }
+
+func TestFilterBlock_Operators(t *testing.T) {
+	// whether a signal passes with 0, 1 or 2 of its conditions holding
+	for op, expected := range map[string][]bool{
+		"ALL":  {false, false, true},
+		"ANY":  {false, true, true},
+		"NONE": {true, false, false},
+		"XOR":  {false, true, false},
+	} {
+		t.Run(op, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer func() {
+				cancel()
+				<-ctx.Done()
+			}()
+
+			b := FilterBlock{}
+
+			if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Filter",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"operator": "` + op + `",
+	"emit_empty": true,
+	"conditions": [
+		{ "expr": "{{ $n > 0 }}" },
+		{ "expr": "{{ $n > 1 }}" }
+	]
+}`)); err != nil {
+				t.Fatal(err)
+			}
+
+			go b.Start(ctx)
+
+			for n, passes := range expected {
+				put(t, &b, nio.DefaultTerminal, nio.Signal{"n": n})
+				trueSignals := takeOne(t, b.ChOutLeft, &b.Busy)
+				falseSignals := takeOne(t, b.ChOutRight, &b.Busy)
+
+				if passes {
+					assert.Len(trueSignals, 1, "%d passing conditions", n)
+					assert.Len(falseSignals, 0, "%d passing conditions", n)
+				} else {
+					assert.Len(trueSignals, 0, "%d passing conditions", n)
+					assert.Len(falseSignals, 1, "%d passing conditions", n)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterBlock_InvalidOperator(t *testing.T) {
+	b := FilterBlock{}
+
+	assert.Error(t, b.Configure(nio.RawBlockConfig(`{
+	"type": "Filter",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"operator": "SOME",
+	"conditions": [{ "expr": true }]
+}`)))
+}
+
+func TestFilterBlock_EmitEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := FilterBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Filter",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"emit_order": "false_first",
+	"emit_empty": true,
+	"conditions": [{ "expr": true }]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{})
+	assert.Len(takeOne(t, b.ChOutLeft, &b.Busy), 1)
+	assert.Len(takeOne(t, b.ChOutRight, &b.Busy), 0)
+}
+
+func TestFilterBlock_IndexAttr(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := FilterBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Filter",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"index_attr": "idx",
+	"conditions": [{ "expr": "{{ $bool }}" }]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	input := nio.SignalGroup{
+		nio.Signal{"bool": true},
+		nio.Signal{"bool": false},
+		nio.Signal{"bool": true},
+	}
+	b.Enqueue(nio.DefaultTerminal, input)
+
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"bool": true, "idx": 0},
+		nio.Signal{"bool": true, "idx": 2},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"bool": false, "idx": 1},
+	}, takeOne(t, b.ChOutRight, &b.Busy))
+	assert.NotContains(input[0], "idx", "input should be untouched")
+}
+
+func TestFilterBlock_ExpressionError(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := newRecordedMetrics()
+	b := FilterBlock{Metrics: metrics}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Filter",
+	"emit_empty": true,
+	"emit_order": "false_first",
+	"index_attr": "index",
+	"conditions": [{ "expr": "{{ $n > 0 }}" }]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"n": "x"}, nio.Signal{"n": 1})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"n": 1, "index": 1},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+	assert.Empty(takeOne(t, b.ChOutRight, &b.Busy))
+	assert.Equal(map[string]int{"conditions[0].expr": 1}, metrics.expressionErrors["Filter"])
+}
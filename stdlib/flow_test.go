@@ -0,0 +1,53 @@
+package stdlib_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-blocks/stdlib/blocktest"
+	"github.com/niolabs/gonio-framework"
+)
+
+// flowTypes are the block types available to the flows in testdata/flows.
+var flowTypes = map[string]nio.BlockTypeEntry{
+	"CounterIntervalSimulator": stdlib.CounterIntervalSimulator,
+	"Modifier":                 stdlib.Modifier,
+	"Noop":                     stdlib.Noop,
+	"Filter":                   stdlib.Filter,
+	"Counter":                  stdlib.Counter,
+	"AttributeSelector":        stdlib.AttributeSelector,
+	"MergeStreams":             stdlib.MergeStreams,
+}
+
+// TestFlows runs every flow in testdata/flows and compares what its blocks
+// emit with the golden file next to it.
+func TestFlows(t *testing.T) {
+	paths, err := filepath.Glob("testdata/flows/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".golden.json") {
+			continue
+		}
+
+		name := strings.TrimSuffix(path, ".json")
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			config, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			g, err := blocktest.LoadGraph(t, flowTypes, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			blocktest.AssertGolden(t, name+".golden.json", g.Run())
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package stdlib
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/mixins"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// GroupStateConfig holds the properties that bound the state grouped blocks
+// keep for each group. A group expires once it has gone untouched for
+// group_ttl, and the least recently touched group is evicted whenever there
+// would be more than max_groups. Zero leaves either bound unset, as it is by
+// default, unless a block gives max_groups a default of its own.
+//
+// Evicting never waits for the evicted output to be read: an evicted signal
+// that finds the output full is dropped, and counted as dropped with the
+// reason "evicted_output_full".
+type GroupStateConfig struct {
+	GroupTTL    *props.TimeDeltaProperty `json:"group_ttl" title:"Group Expiry" order:"30" advanced:"true" default:"{\"seconds\": 0}"`
+	MaxGroups   *props.IntProperty       `json:"max_groups" title:"Maximum Groups" order:"31" advanced:"true" default:"0"`
+	EmitEvicted *props.BooleanProperty   `json:"emit_evicted" title:"Emit Evicted Groups" order:"32" advanced:"true" default:"false"`
+}
+
+// groupEvictedTerminal is the output on which grouped blocks emit a signal
+// for every group they evict, when emit_evicted is set.
+const groupEvictedTerminal nio.Terminal = "evicted"
+
+// groupEvictedOutput is the definition of the evicted output, ordered after
+// the other outputs of a block.
+func groupEvictedOutput(order int) nio.TerminalDefinition {
+	return nio.TerminalDefinition{
+		Label:   string(groupEvictedTerminal),
+		Type:    "output",
+		Visible: true,
+		Order:   order,
+		ID:      string(groupEvictedTerminal),
+		Default: false,
+	}
+}
+
+// Reasons a group is evicted, as given in the reason of evicted signals.
+const (
+	groupEvictedExpired  = "expired"
+	groupEvictedCapacity = "capacity"
+)
+
+// groupState is the state of a grouped block, one value per group, bounded
+// by GroupStateConfig. Every method must be called with the lock it was
+// configured with held; it is taken by the state itself only to expire
+// groups from a timer.
+type groupState struct {
+	ttl       time.Duration
+	maxGroups int64
+	emit      bool
+
+	// defaultMaxGroups, when set before the state is configured, is the
+	// max_groups of a block that leaves it unset.
+	defaultMaxGroups int64
+
+	// onEvict, when set, releases whatever else a block holds for an
+	// evicted group. It is called with the lock held.
+	onEvict func(group mixins.Group, value interface{})
+	// addGroup labels evicted signals with their group.
+	addGroup func(group mixins.Group, signal nio.Signal, force bool)
+
+	clock   Clock
+	lock    sync.Locker
+	metrics blockMetrics
+
+	entries map[mixins.Group]*list.Element
+	// lru orders the entries from the most to the least recently touched
+	lru     *list.List
+	timer   Timer
+	stopped bool
+
+	terminal nio.Terminal
+	out      chan nio.SignalGroup
+}
+
+type groupStateEntry struct {
+	group   mixins.Group
+	value   interface{}
+	touched time.Time
+}
+
+// configure reads the bounds of the state, recording their errors in c, and
+// empties it.
+func (s *groupState) configure(c *configCheck, config *GroupStateConfig, clock Clock, lock sync.Locker, metrics blockMetrics) {
+	c.add("group_ttl", config.GroupTTL.AssignToDefault(&s.ttl, nil, 0))
+	c.add("max_groups", config.MaxGroups.AssignToDefault(&s.maxGroups, nil, s.defaultMaxGroups))
+	c.add("emit_evicted", config.EmitEvicted.AssignToDefault(&s.emit, nil, false))
+
+	s.clock = clockOrReal(clock)
+	s.lock = lock
+	s.metrics = metrics
+	s.entries = map[mixins.Group]*list.Element{}
+	s.lru = list.New()
+	s.timer = nil
+	s.stopped = false
+	s.terminal = groupEvictedTerminal
+	s.out = newOutputChannel()
+}
+
+// eachOutput passes the evicted output to fn.
+func (s *groupState) eachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	fn(s.terminal, s.out)
+}
+
+// get returns the value of a group and touches it. Expired groups are
+// evicted rather than returned.
+func (s *groupState) get(group mixins.Group) (interface{}, bool) {
+	element, ok := s.entries[group]
+	if !ok {
+		return nil, false
+	}
+
+	now := s.clock.Now()
+	entry := element.Value.(*groupStateEntry)
+	if s.expired(entry, now) {
+		s.evict(element, groupEvictedExpired)
+		return nil, false
+	}
+
+	entry.touched = now
+	s.lru.MoveToFront(element)
+	return entry.value, true
+}
+
+// peek returns the value of a group without touching it, for inspecting the
+// state rather than using it.
+func (s *groupState) peek(group mixins.Group) (interface{}, bool) {
+	element, ok := s.entries[group]
+	if !ok || s.expired(element.Value.(*groupStateEntry), s.clock.Now()) {
+		return nil, false
+	}
+	return element.Value.(*groupStateEntry).value, true
+}
+
+// set sets and touches the value of a group, evicting the least recently
+// touched groups beyond max_groups.
+func (s *groupState) set(group mixins.Group, value interface{}) {
+	now := s.clock.Now()
+
+	if element, ok := s.entries[group]; ok {
+		entry := element.Value.(*groupStateEntry)
+		entry.value, entry.touched = value, now
+		s.lru.MoveToFront(element)
+	} else {
+		s.entries[group] = s.lru.PushFront(&groupStateEntry{group: group, value: value, touched: now})
+	}
+
+	for s.maxGroups > 0 && int64(len(s.entries)) > s.maxGroups {
+		s.evict(s.lru.Back(), groupEvictedCapacity)
+	}
+
+	s.schedule()
+	s.metrics.stateSize(len(s.entries))
+}
+
+// delete forgets a group without evicting it.
+func (s *groupState) delete(group mixins.Group) {
+	if element, ok := s.entries[group]; ok {
+		s.lru.Remove(element)
+		delete(s.entries, group)
+		s.metrics.stateSize(len(s.entries))
+	}
+}
+
+// clear forgets every group without evicting them.
+func (s *groupState) clear() {
+	s.entries = map[mixins.Group]*list.Element{}
+	s.lru.Init()
+	s.metrics.stateSize(0)
+}
+
+// each calls fn with every group held, from the most recently touched,
+// without touching them.
+func (s *groupState) each(fn func(group mixins.Group, value interface{})) {
+	now := s.clock.Now()
+	for element := s.lru.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*groupStateEntry)
+		if !s.expired(entry, now) {
+			fn(entry.group, entry.value)
+		}
+	}
+}
+
+func (s *groupState) expired(entry *groupStateEntry, now time.Time) bool {
+	return s.ttl > 0 && now.Sub(entry.touched) >= s.ttl
+}
+
+// evict forgets the group of element, and emits an evicted signal for it
+// unless the evicted output is full, as the lock is held.
+func (s *groupState) evict(element *list.Element, reason string) {
+	entry := element.Value.(*groupStateEntry)
+	s.lru.Remove(element)
+	delete(s.entries, entry.group)
+	s.metrics.stateSize(len(s.entries))
+
+	if s.onEvict != nil {
+		s.onEvict(entry.group, entry.value)
+	}
+
+	if s.emit {
+		signal := nio.Signal{"reason": reason}
+		if s.addGroup != nil {
+			s.addGroup(entry.group, signal, true)
+		}
+		signals := nio.SignalGroup{signal}
+		select {
+		case s.out <- signals:
+			s.metrics.out(s.terminal, signals)
+		default:
+			s.metrics.dropped("evicted_output_full", len(signals))
+		}
+	}
+}
+
+// schedule arms the timer that expires the least recently touched group.
+func (s *groupState) schedule() {
+	if s.ttl <= 0 || s.stopped || s.timer != nil || s.lru.Len() == 0 {
+		return
+	}
+
+	oldest := s.lru.Back().Value.(*groupStateEntry)
+	s.timer = s.clock.AfterFunc(oldest.touched.Add(s.ttl).Sub(s.clock.Now()), s.sweep)
+}
+
+// sweep evicts every expired group, and rearms the timer for those left.
+func (s *groupState) sweep() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.timer = nil
+	if s.stopped {
+		return
+	}
+
+	now := s.clock.Now()
+	for element := s.lru.Back(); element != nil; element = s.lru.Back() {
+		if !s.expired(element.Value.(*groupStateEntry), now) {
+			break
+		}
+		s.evict(element, groupEvictedExpired)
+	}
+
+	s.schedule()
+}
+
+// stop disarms the expiry timer, for when the block stops.
+func (s *groupState) stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stopped = true
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
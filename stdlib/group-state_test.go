@@ -0,0 +1,242 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+// evictedOutput returns the evicted output of a grouped block.
+func evictedOutput(b nio.Block) <-chan nio.SignalGroup {
+	var evicted <-chan nio.SignalGroup
+	b.EachOutput(func(terminal nio.Terminal, ch <-chan nio.SignalGroup) {
+		if terminal == "evicted" {
+			evicted = ch
+		}
+	})
+	return evicted
+}
+
+func TestGroupState_Expiry(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.CounterBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Counter",
+	"group_by": "{{ $group }}",
+	"group_ttl": {"seconds": 10},
+	"emit_evicted": true
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	evicted := evictedOutput(&b)
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a"})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	clock.Advance(6 * time.Second)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "b"})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	// a expires ten seconds after it was last touched, b has four to go
+	clock.Advance(5 * time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "a", "reason": "expired"},
+	}, takeOne(t, evicted, &b.Busy))
+	takeNone(t, evicted, &b.Busy)
+
+	// touching b keeps it alive
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "b"}, nio.Signal{"group": "a"})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "b", "count": 1, "cumulative_count": 2},
+		nio.Signal{"group": "a", "count": 1, "cumulative_count": 1},
+	}, takeOne(t, b.ChOut, &b.Busy))
+
+	clock.Advance(9 * time.Second)
+	takeNone(t, evicted, &b.Busy)
+
+	clock.Advance(time.Second)
+	signals := takeOne(t, evicted, &b.Busy)
+	assert.ElementsMatch(nio.SignalGroup{
+		nio.Signal{"group": "a", "reason": "expired"},
+		nio.Signal{"group": "b", "reason": "expired"},
+	}, append(signals, takeOne(t, evicted, &b.Busy)...))
+}
+
+func TestGroupState_MaxGroups(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.CounterBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Counter",
+	"group_by": "{{ $group }}",
+	"max_groups": 2,
+	"emit_evicted": true
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	evicted := evictedOutput(&b)
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a"})
+	takeOne(t, b.ChOut, &b.Busy)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "b"})
+	takeOne(t, b.ChOut, &b.Busy)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a"})
+	takeOne(t, b.ChOut, &b.Busy)
+	takeNone(t, evicted, &b.Busy)
+
+	// b is the least recently touched
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "c"})
+	takeOne(t, b.ChOut, &b.Busy)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "b", "reason": "capacity"},
+	}, takeOne(t, evicted, &b.Busy))
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a"}, nio.Signal{"group": "b"})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "a", "count": 1, "cumulative_count": 3},
+		nio.Signal{"group": "b", "count": 1, "cumulative_count": 1},
+	}, takeOne(t, b.ChOut, &b.Busy))
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "c", "reason": "capacity"},
+	}, takeOne(t, evicted, &b.Busy))
+}
+
+func TestGroupState_EvictedOutputFull(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := newRecordedMetrics()
+	b := stdlib.CounterBlock{Metrics: metrics}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Counter",
+	"group_by": "{{ $group }}",
+	"max_groups": 1,
+	"emit_evicted": true
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	evicted := evictedOutput(&b)
+	go b.Start(ctx)
+
+	// nothing reads the evicted output, which must not hold up the block
+	var signals nio.SignalGroup
+	for i := 0; i < 100; i++ {
+		signals = append(signals, nio.Signal{"group": i})
+	}
+	put(t, &b, nio.DefaultTerminal, signals...)
+	assert.Len(takeOne(t, b.ChOut, &b.Busy), 100)
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	dropped := metrics.signalsDropped["Counter"]["evicted_output_full"]
+	assert.NotZero(dropped)
+	assert.Equal(99, len(evicted)+dropped)
+}
+
+func TestGroupState_Silent(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.DebounceBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Debounce",
+	"group_by": "{{ $group }}",
+	"interval": {"seconds": 30},
+	"group_ttl": {"seconds": 10}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	evicted := evictedOutput(&b)
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a"})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	// once forgotten, the group is no longer debounced
+	clock.Advance(10 * time.Second)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a", "n": 2})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "a", "n": 2},
+	}, takeOne(t, b.ChOut, &b.Busy))
+	takeNone(t, evicted, &b.Busy)
+}
+
+func TestGroupState_SwitchReset(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.SwitchBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Switch",
+	"state_expr": "{{ $state }}",
+	"group_by": "{{ $group }}",
+	"auto_reset_after": {"seconds": 20},
+	"max_groups": 1
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, "setter", nio.Signal{"state": true, "group": "a"})
+	takeOne(t, b.ChOutState, &b.Busy)
+	put(t, &b, "setter", nio.Signal{"state": true, "group": "b"})
+	takeOne(t, b.ChOutState, &b.Busy)
+
+	// a was evicted along with its pending reset
+	clock.Advance(20 * time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"state": false, "group": "b"},
+	}, takeOne(t, b.ChOutState, &b.Busy))
+	takeNone(t, b.ChOutState, &b.Busy)
+}
+
+func TestGroupState_ConfigErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.MergeStreamsBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "MergeStreams",
+	"max_groups": "many",
+	"emit_evicted": 1
+}`))
+
+	if assert.IsType(stdlib.ConfigErrors{}, err) {
+		var properties []string
+		for _, e := range err.(stdlib.ConfigErrors) {
+			properties = append(properties, e.Property)
+		}
+		assert.Equal([]string{"max_groups", "emit_evicted"}, properties)
+	}
+}
@@ -1,9 +1,141 @@
 package stdlib
 
-import "github.com/niolabs/gonio-framework"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/props"
+)
 
 func SetTerminal(terminal *nio.Terminal, defaultValue nio.Terminal) {
 	if *terminal == "" {
 		*terminal = defaultValue
 	}
 }
+
+// outputBufferSize is the capacity of channels backing output terminals that
+// a block declares in addition to those of its embedded nio type.
+const outputBufferSize = 16
+
+func newOutputChannel() chan nio.SignalGroup {
+	return make(chan nio.SignalGroup, outputBufferSize)
+}
+
+// getPath returns the value at a dotted attribute path, and whether there is
+// one.
+func getPath(signal nio.Signal, path string) (interface{}, bool) {
+	var value interface{} = signal
+	for _, key := range strings.Split(path, ".") {
+		parent, ok := asMap(value)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = parent[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// setPath assigns value to a dotted attribute path such as "a.b.c",
+// creating intermediate maps as needed. Nested maps along the path are
+// copied before they are written so that signals sharing them (such as a
+// shallow clone of an incoming signal) are left untouched.
+func setPath(signal nio.Signal, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	parent := map[string]interface{}(signal)
+
+	for _, key := range keys[:len(keys)-1] {
+		child := cloneMap(parent[key])
+		parent[key] = child
+		parent = child
+	}
+
+	parent[keys[len(keys)-1]] = value
+}
+
+// deletePath removes the attribute at a dotted path. Missing intermediate
+// attributes are ignored.
+func deletePath(signal nio.Signal, path string) {
+	keys := strings.Split(path, ".")
+	parent := map[string]interface{}(signal)
+
+	for _, key := range keys[:len(keys)-1] {
+		if _, ok := asMap(parent[key]); !ok {
+			return
+		}
+		child := cloneMap(parent[key])
+		parent[key] = child
+		parent = child
+	}
+
+	delete(parent, keys[len(keys)-1])
+}
+
+func asMap(value interface{}) (map[string]interface{}, bool) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m, true
+	case nio.Signal:
+		return m, true
+	default:
+		return nil, false
+	}
+}
+
+// cloneMap returns a shallow copy of value if it is a map, or a new empty
+// map otherwise.
+func cloneMap(value interface{}) map[string]interface{} {
+	next := map[string]interface{}{}
+	if m, ok := asMap(value); ok {
+		for k, v := range m {
+			next[k] = v
+		}
+	}
+	return next
+}
+
+// toFloat converts a numeric signal or property value to a float64.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// assignFloatDefault evaluates a numeric property without a signal.
+func assignFloatDefault(prop *props.AnyProperty, dst *float64, defaultValue float64) error {
+	value, err := prop.InvokeDefault(nil, defaultValue)
+	if err != nil {
+		return err
+	}
+
+	f, ok := toFloat(value)
+	if !ok {
+		return fmt.Errorf("`%v' is not a number", value)
+	}
+
+	*dst = f
+	return nil
+}
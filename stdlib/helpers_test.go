@@ -3,10 +3,17 @@ package stdlib_test
 import (
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/niolabs/gonio-blocks/stdlib"
 	"github.com/niolabs/gonio-framework"
 )
 
+// hangGuard bounds the real-time waits of the tests. They synchronise with
+// blocks through Busy and FakeClock, so the guard only turns a hang into a
+// failure.
+const hangGuard = 5 * time.Second
+
 func put(t *testing.T, b nio.Block, terminal string, signals ...nio.Signal) {
 	if err := b.Enqueue(nio.Terminal(terminal), signals); err != nil {
 		t.Error(err)
@@ -35,3 +42,54 @@ func takeNone(t *testing.T, b <-chan nio.SignalGroup, wg *sync.WaitGroup) nio.Si
 		return nil
 	}
 }
+
+// takeWithin waits for signals that are notified asynchronously, outside of
+// the block's Busy accounting.
+func takeWithin(t *testing.T, b <-chan nio.SignalGroup) nio.SignalGroup {
+	select {
+	case signals := <-b:
+		return signals
+	case <-time.After(hangGuard):
+		t.Errorf("channel has no signals after %s", hangGuard)
+	}
+
+	return nil
+}
+
+// expectNone checks that a channel fed by another goroutine has no signals.
+// Call it once that goroutine is synchronised with, by settle or by waiting
+// for it to return.
+func expectNone(t *testing.T, b <-chan nio.SignalGroup) {
+	select {
+	case signals := <-b:
+		t.Errorf("channel has %d signals", len(signals))
+	default:
+	}
+}
+
+// settle waits until n timers are pending on clock. A simulator arms its
+// next timer only once it has sent what the last one fired for, so once it
+// settles, whatever it sends before the clock moves again is on its output.
+func settle(t *testing.T, clock *stdlib.FakeClock, n int) {
+	settled := make(chan struct{})
+	go func() {
+		clock.BlockUntil(n)
+		close(settled)
+	}()
+
+	select {
+	case <-settled:
+	case <-time.After(hangGuard):
+		t.Fatalf("clock has fewer than %d timers pending after %s", n, hangGuard)
+	}
+}
+
+// waitReturn waits for a block's Start, run by the caller in a goroutine
+// that closes done, to return.
+func waitReturn(t *testing.T, done <-chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(hangGuard):
+		t.Fatalf("Start has not returned after %s", hangGuard)
+	}
+}
@@ -0,0 +1,141 @@
+package stdlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// Properties are re-evaluated for every signal even when their configured
+// value holds no expression at all. The hoisted* types below evaluate such
+// constant properties once, at Configure time, and only defer to the
+// underlying property when it actually depends on the signal.
+
+var exprDelimiter = []byte("{{")
+
+// isConstantExpr reports whether a raw property value can be evaluated
+// without a signal. Missing properties are constant: they always resolve to
+// their default.
+func isConstantExpr(raw json.RawMessage) bool {
+	return !bytes.Contains(raw, exprDelimiter)
+}
+
+// rawPropertyList splits a raw JSON list of objects, as used by list
+// properties such as Filter conditions, into their raw property values.
+func rawPropertyList(list json.RawMessage) ([]map[string]json.RawMessage, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(list, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+type hoistedBool struct {
+	prop     *props.BooleanProperty
+	value    bool
+	def      bool
+	constant bool
+}
+
+func hoistBool(prop *props.BooleanProperty, raw json.RawMessage, def bool) (hoistedBool, error) {
+	h := hoistedBool{prop: prop, def: def, constant: isConstantExpr(raw)}
+	if h.constant {
+		value, err := prop.InvokeDefault(nil, def)
+		if err != nil {
+			return h, err
+		}
+		h.value = value
+	}
+	return h, nil
+}
+
+func (h *hoistedBool) Invoke(signal nio.Signal) (bool, error) {
+	if h.constant {
+		return h.value, nil
+	}
+	return h.prop.InvokeDefault(signal, h.def)
+}
+
+type hoistedString struct {
+	prop     *props.StringProperty
+	value    string
+	def      string
+	constant bool
+}
+
+func hoistString(prop *props.StringProperty, raw json.RawMessage, def string) (hoistedString, error) {
+	h := hoistedString{prop: prop, def: def, constant: isConstantExpr(raw)}
+	if h.constant {
+		value, err := prop.InvokeDefault(nil, def)
+		if err != nil {
+			return h, err
+		}
+		h.value = value
+	}
+	return h, nil
+}
+
+func (h *hoistedString) Invoke(signal nio.Signal) (string, error) {
+	if h.constant {
+		return h.value, nil
+	}
+	return h.prop.InvokeDefault(signal, h.def)
+}
+
+type hoistedAny struct {
+	prop     *props.AnyProperty
+	value    interface{}
+	def      interface{}
+	constant bool
+}
+
+func hoistAny(prop *props.AnyProperty, raw json.RawMessage, def interface{}) (hoistedAny, error) {
+	h := hoistedAny{prop: prop, def: def, constant: isConstantExpr(raw)}
+	if h.constant {
+		value, err := prop.InvokeDefault(nil, def)
+		if err != nil {
+			return h, err
+		}
+		h.value = value
+	}
+	return h, nil
+}
+
+func (h *hoistedAny) Invoke(signal nio.Signal) (interface{}, error) {
+	if h.constant {
+		return h.value, nil
+	}
+	return h.prop.InvokeDefault(signal, h.def)
+}
+
+type hoistedDuration struct {
+	prop     *props.TimeDeltaProperty
+	value    time.Duration
+	def      time.Duration
+	constant bool
+}
+
+func hoistDuration(prop *props.TimeDeltaProperty, raw json.RawMessage, def time.Duration) (hoistedDuration, error) {
+	h := hoistedDuration{prop: prop, def: def, constant: isConstantExpr(raw)}
+	if h.constant {
+		value, err := prop.InvokeDefault(nil, def)
+		if err != nil {
+			return h, err
+		}
+		h.value = value
+	}
+	return h, nil
+}
+
+func (h *hoistedDuration) Invoke(signal nio.Signal) (time.Duration, error) {
+	if h.constant {
+		return h.value, nil
+	}
+	return h.prop.InvokeDefault(signal, h.def)
+}
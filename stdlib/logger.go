@@ -2,9 +2,9 @@ package stdlib
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"os"
+	"time"
 
 	"github.com/niolabs/gonio-framework"
 )
@@ -14,6 +14,12 @@ type LoggerBlock struct {
 	nio.Consumer
 	Config nio.BlockConfigAtom
 	*log.Logger
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	metrics blockMetrics
 }
 
 func (lb *LoggerBlock) Configure(config nio.RawBlockConfig) error {
@@ -23,14 +29,18 @@ func (lb *LoggerBlock) Configure(config nio.RawBlockConfig) error {
 
 	lb.Consumer.Configure()
 
-	if err := json.Unmarshal(config, &lb.Config); err != nil {
+	c, err := decodeConfig(config, &lb.Config)
+	if err != nil {
 		return err
 	}
+	lb.metrics = newBlockMetrics(lb.Metrics, c.atom)
 
-	return nil
+	return c.err()
 }
 
 func (lb *LoggerBlock) process(signals nio.SignalGroup) {
+	defer lb.metrics.processed(time.Now())
+
 	for _, sig := range signals {
 		lb.Logger.Printf("%+v\n", sig)
 	}
@@ -49,16 +59,19 @@ func (lb *LoggerBlock) Start(ctx context.Context) {
 }
 
 func (lb *LoggerBlock) Enqueue(t nio.Terminal, signals nio.SignalGroup) error {
+	lb.metrics.in(t, signals)
 	return lb.Consumer.Enqueue(t, signals, 1)
 }
 
 func newLoggerBlock() nio.Block { return &LoggerBlock{} }
 
+const loggerVersion = "1.1.0"
+
 var Logger = nio.BlockTypeEntry{
 	Create: newLoggerBlock,
 	Definition: nio.BlockTypeDefinition{
 		Name:      "Logger",
-		Version:   "1.1.0",
+		Version:   loggerVersion,
 		Namespace: "goblocks.logger.logger_block.Logger",
 		BlockAttributes: nio.BlockAttributes{
 			Inputs: []nio.TerminalDefinition{
@@ -73,100 +86,8 @@ var Logger = nio.BlockTypeEntry{
 			},
 			Outputs: []nio.TerminalDefinition{},
 		},
-		Properties: map[nio.Property]nio.PropertyDefinition{
-			"version": {
-				"title":      "Version",
-				"order":      nil,
-				"type":       "StringType",
-				"default":    "1.1.0",
-				"advanced":   true,
-				"allow_none": false,
-				"visible":    true,
-			},
-			"name": {
-				"title":      "Name",
-				"order":      nil,
-				"type":       "StringType",
-				"default":    nil,
-				"advanced":   false,
-				"allow_none": true,
-				"visible":    false,
-			},
-			"id": {
-				"title":      "Id",
-				"order":      nil,
-				"type":       "StringType",
-				"default":    nil,
-				"advanced":   false,
-				"allow_none": false,
-				"visible":    false,
-			},
-			"log_as_list": {
-				"title":      "Log as a list",
-				"order":      nil,
-				"type":       "BoolType",
-				"default":    false,
-				"advanced":   false,
-				"allow_none": false,
-				"visible":    false,
-			},
-			"log_at": {
-				"title":      "Log At",
-				"advanced":   false,
-				"allow_none": false,
-				"visible":    true,
-				"enum":       "LogLevel",
-				"order":      nil,
-				"type":       "SelectType",
-				"default":    "INFO",
-				"options": map[string]int{
-					"CRITICAL": 50,
-					"DEBUG":    10,
-					"ERROR":    40,
-					"WARNING":  30,
-					"INFO":     20,
-					"NOTSET":   0,
-				},
-			},
-			"log_level": {
-				"title":      "Log Level",
-				"advanced":   false,
-				"allow_none": false,
-				"visible":    true,
-				"enum":       "LogLevel",
-				"order":      nil,
-				"type":       "SelectType",
-				"default":    "INFO",
-				"options": map[string]int{
-					"CRITICAL": 50,
-					"DEBUG":    10,
-					"ERROR":    40,
-					"WARNING":  30,
-					"INFO":     20,
-					"NOTSET":   0,
-				},
-			},
-			"log_hidden_attributes": {
-				"title":      "Log Hidden Attributes",
-				"order":      nil,
-				"type":       "BoolType",
-				"default":    false,
-				"advanced":   false,
-				"allow_none": false,
-				"visible":    true,
-			},
-			"type": {
-				"title":      "Type",
-				"readonly":   true,
-				"visible":    false,
-				"advanced":   false,
-				"allow_none": false,
-				"order":      nil,
-				"type":       "StringType",
-				"default":    nil,
-			},
-		},
-		Commands: map[nio.Command]nio.CommandDefinition{},
+		Properties: definitionProperties(nio.BlockConfigAtom{}, loggerVersion),
+		Commands:   map[nio.Command]nio.CommandDefinition{},
 	},
 }
 
@@ -2,7 +2,6 @@ package stdlib
 
 import (
 	"context"
-	"encoding/json"
 	"sync"
 
 	"github.com/niolabs/gonio-framework"
@@ -15,15 +14,31 @@ type MergeStreamsBlock struct {
 	mixins.GroupByMixin
 	Config MergeStreamsBlockConfig
 
-	once       bool
-	mutex      sync.Mutex
-	leftCache  map[mixins.Group]nio.Signal
-	rightCache map[mixins.Group]nio.Signal
+	// Clock expires groups. It defaults to RealClock.
+	Clock Clock
+
+	once   bool
+	mutex  sync.Mutex
+	groups groupState
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	metrics blockMetrics
 }
 
 type MergeStreamsBlockConfig struct {
 	nio.BlockConfigAtom
-	Once *props.BooleanProperty `json:"notify_once"`
+	Once *props.BooleanProperty `json:"notify_once" title:"Notify Once?" order:"0" default:"true"`
+	GroupStateConfig
+}
+
+// mergeStreamsEntry holds the last signal of a group on either input, or nil
+// while there is none to merge with.
+type mergeStreamsEntry struct {
+	left  nio.Signal
+	right nio.Signal
 }
 
 func (b *MergeStreamsBlock) Configure(config nio.RawBlockConfig) error {
@@ -31,38 +46,45 @@ func (b *MergeStreamsBlock) Configure(config nio.RawBlockConfig) error {
 	SetTerminal(&b.TInRight, "input_2")
 	b.Joiner.Configure()
 
-	if err := b.GroupByMixin.Configure(config, b.Notify); err != nil {
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
 		return err
 	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
 
-	if err := json.Unmarshal(config, &b.Config); err != nil {
-		return err
-	}
+	c.add("group_by", b.GroupByMixin.Configure(config, b.metrics.notify(b.Notify)))
+	c.add("notify_once", b.Config.Once.AssignToDefault(&b.once, nil, true))
 
-	if err := b.Config.Once.AssignToDefault(&b.once, nil, true); err != nil {
-		return err
-	}
+	b.groups.configure(c, &b.Config.GroupStateConfig, b.Clock, &b.mutex, b.metrics)
+	b.groups.addGroup = b.GroupByMixin.AddGroupToSignal
 
-	b.leftCache = map[mixins.Group]nio.Signal{}
-	b.rightCache = map[mixins.Group]nio.Signal{}
+	return c.err()
+}
 
-	return nil
+func (b *MergeStreamsBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	b.Joiner.EachOutput(fn)
+	b.groups.eachOutput(fn)
 }
 
 func (b *MergeStreamsBlock) Start(ctx context.Context) {
+	defer b.groups.stop()
+
 	for {
 		select {
 		case signals := <-b.ChInLeft:
-			b.GroupByMixin.Process(signals, b.processLeft)
+			b.GroupByMixin.Process(signals, b.metrics.group(b.processLeft))
 			b.Busy.Done()
 		case signals := <-b.ChInRight:
-			b.GroupByMixin.Process(signals, b.processRight)
+			b.GroupByMixin.Process(signals, b.metrics.group(b.processRight))
 			b.Busy.Done()
+		case <-ctx.Done():
+			return
 		}
 	}
 }
 
 func (b *MergeStreamsBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
 	return b.DualConsumer.Enqueue(terminal, signals, 1)
 }
 
@@ -70,9 +92,12 @@ func (b *MergeStreamsBlock) processLeft(group mixins.Group, notify nio.NotifyFun
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
-	if rightSignal, ok := b.rightCache[group]; ok {
+	entry := b.entry(group)
+	defer b.store(group, entry)
+
+	if rightSignal := entry.right; rightSignal != nil {
 		if b.once {
-			delete(b.rightCache, group)
+			entry.right = nil
 			firstSignal := inSignals[0]
 			notify(b.TOut, nio.SignalGroup{firstSignal.CloneWith(rightSignal)})
 			return nil
@@ -89,7 +114,7 @@ func (b *MergeStreamsBlock) processLeft(group mixins.Group, notify nio.NotifyFun
 	}
 
 	last := inSignals[len(inSignals)-1]
-	b.leftCache[group] = last
+	entry.left = last
 	return nil
 }
 
@@ -97,9 +122,12 @@ func (b *MergeStreamsBlock) processRight(group mixins.Group, notify nio.NotifyFu
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
-	if leftSignal, ok := b.leftCache[group]; ok {
+	entry := b.entry(group)
+	defer b.store(group, entry)
+
+	if leftSignal := entry.left; leftSignal != nil {
 		if b.once {
-			delete(b.leftCache, group)
+			entry.left = nil
 			firstSignal := inSignals[0]
 			notify(b.TOut, nio.SignalGroup{leftSignal.CloneWith(firstSignal)})
 			return nil
@@ -116,6 +144,115 @@ func (b *MergeStreamsBlock) processRight(group mixins.Group, notify nio.NotifyFu
 	}
 
 	last := inSignals[len(inSignals)-1]
-	b.rightCache[group] = last
+	entry.right = last
 	return nil
 }
+
+// entry returns the cached signals of a group. It must be called with the
+// mutex held.
+func (b *MergeStreamsBlock) entry(group mixins.Group) *mergeStreamsEntry {
+	if entry, ok := b.groups.get(group); ok {
+		return entry.(*mergeStreamsEntry)
+	}
+	return &mergeStreamsEntry{}
+}
+
+// store keeps the cached signals of a group, forgetting the group once it
+// has none. It must be called with the mutex held.
+func (b *MergeStreamsBlock) store(group mixins.Group, entry *mergeStreamsEntry) {
+	if entry.left == nil && entry.right == nil {
+		b.groups.delete(group)
+	} else {
+		b.groups.set(group, entry)
+	}
+}
+
+// Command inspects or clears the cached signals. cached returns the last
+// signal of every group on each input still waiting to be merged; clear
+// forgets those of the group given, or of every group, and returns the
+// signals left.
+func (b *MergeStreamsBlock) Command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch command {
+	case "cached":
+	case "clear":
+		if group, ok := groupArg(args); ok {
+			b.groups.delete(group)
+		} else {
+			b.groups.clear()
+		}
+	default:
+		return nil, unknownCommand(command)
+	}
+
+	cached := map[string]map[nio.Terminal]nio.Signal{}
+	b.groups.each(func(group mixins.Group, value interface{}) {
+		entry := value.(*mergeStreamsEntry)
+		signals := map[nio.Terminal]nio.Signal{}
+		if entry.left != nil {
+			signals[b.TInLeft] = entry.left
+		}
+		if entry.right != nil {
+			signals[b.TInRight] = entry.right
+		}
+		cached[string(group)] = signals
+	})
+	return cached, nil
+}
+
+const mergeStreamsVersion = "0.2.0"
+
+var MergeStreams = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &MergeStreamsBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.merge_streams.merge_streams_block.MergeStreams",
+		Version:    mergeStreamsVersion,
+		Name:       "MergeStreams",
+		Properties: groupedProperties(MergeStreamsBlockConfig{}, mergeStreamsVersion),
+		Commands: map[nio.Command]nio.CommandDefinition{
+			"cached": {
+				"title":  "Cached Signals",
+				"params": map[string]interface{}{},
+			},
+			"clear": {
+				"title": "Clear",
+				"params": map[string]interface{}{
+					"group": groupParam(true),
+				},
+			},
+		},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "input_1",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "input_1",
+					Default: true,
+				},
+				{
+					Label:   "input_2",
+					Type:    "input",
+					Visible: true,
+					Order:   1,
+					ID:      "input_2",
+					Default: false,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+				groupEvictedOutput(1),
+			},
+		},
+	},
+}
@@ -132,3 +132,56 @@ func TestMergeStreamsBlock_Multi(t *testing.T) {
 	}
 
 }
+
+func TestMergeStreamsBlock_Commands(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.MergeStreamsBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "MergeStreams",
+	"group_by": "{{ $group }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, "input_1", nio.Signal{"group": "a", "foo": 1}, nio.Signal{"group": "b", "foo": 2})
+	takeNone(t, b.ChOut, &b.Busy)
+
+	cached, err := b.Command("cached", nil)
+	assert.NoError(err)
+	assert.Equal(map[string]map[nio.Terminal]nio.Signal{
+		"a": {"input_1": {"group": "a", "foo": 1}},
+		"b": {"input_1": {"group": "b", "foo": 2}},
+	}, cached)
+
+	cached, err = b.Command("clear", map[string]interface{}{"group": "a"})
+	assert.NoError(err)
+	assert.Len(cached, 1)
+
+	// a no longer has a signal to merge with
+	put(t, &b, "input_2", nio.Signal{"group": "a", "bar": 1})
+	takeNone(t, b.ChOut, &b.Busy)
+
+	cached, err = b.Command("clear", nil)
+	assert.NoError(err)
+	assert.Empty(cached)
+}
+
+func TestMergeStreamsBlock_ConfigErrors(t *testing.T) {
+	b := stdlib.MergeStreamsBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "MergeStreams",
+	"notify_once": 3
+}`))
+
+	// the error names the property as configured
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "MergeStreams: notify_once: ")
+	}
+}
@@ -0,0 +1,116 @@
+package stdlib
+
+import (
+	"expvar"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+)
+
+// ExpvarMetrics publishes the metrics of blocks as an expvar map, served with
+// every other expvar on /debug/vars. The map holds a map for each block:
+//
+//	signals_in         signals by input terminal
+//	signals_out        signals by output terminal
+//	expression_errors  errors by property
+//	groups_processed   the number of groups processed
+//	processing_ns      the total time taken to process them
+//	latency_buckets    groups processed by the upper bound of their latency,
+//	                   in nanoseconds, or +Inf
+//	state_size         the groups held state for
+//	signals_dropped    signals discarded by reason
+type ExpvarMetrics struct {
+	root *expvar.Map
+
+	mutex  sync.Mutex
+	blocks map[string]*expvarBlock
+}
+
+type expvarBlock struct {
+	signalsIn        *expvar.Map
+	signalsOut       *expvar.Map
+	expressionErrors *expvar.Map
+	groupsProcessed  *expvar.Int
+	processingNanos  *expvar.Int
+	latencyBuckets   *expvar.Map
+	stateSize        *expvar.Int
+	signalsDropped   *expvar.Map
+}
+
+// NewExpvarMetrics publishes empty metrics under name. Like expvar.Publish,
+// it panics if name is already published.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	return &ExpvarMetrics{
+		root:   expvar.NewMap(name),
+		blocks: map[string]*expvarBlock{},
+	}
+}
+
+// block returns the maps of a block, publishing them on first use.
+func (m *ExpvarMetrics) block(name string) *expvarBlock {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if b, ok := m.blocks[name]; ok {
+		return b
+	}
+
+	b := &expvarBlock{
+		signalsIn:        new(expvar.Map).Init(),
+		signalsOut:       new(expvar.Map).Init(),
+		expressionErrors: new(expvar.Map).Init(),
+		groupsProcessed:  new(expvar.Int),
+		processingNanos:  new(expvar.Int),
+		latencyBuckets:   new(expvar.Map).Init(),
+		stateSize:        new(expvar.Int),
+		signalsDropped:   new(expvar.Map).Init(),
+	}
+
+	vars := new(expvar.Map).Init()
+	vars.Set("signals_in", b.signalsIn)
+	vars.Set("signals_out", b.signalsOut)
+	vars.Set("expression_errors", b.expressionErrors)
+	vars.Set("groups_processed", b.groupsProcessed)
+	vars.Set("processing_ns", b.processingNanos)
+	vars.Set("latency_buckets", b.latencyBuckets)
+	vars.Set("state_size", b.stateSize)
+	vars.Set("signals_dropped", b.signalsDropped)
+	m.root.Set(name, vars)
+
+	m.blocks[name] = b
+	return b
+}
+
+func (m *ExpvarMetrics) SignalsIn(block string, terminal nio.Terminal, n int) {
+	m.block(block).signalsIn.Add(string(terminal), int64(n))
+}
+
+func (m *ExpvarMetrics) SignalsOut(block string, terminal nio.Terminal, n int) {
+	m.block(block).signalsOut.Add(string(terminal), int64(n))
+}
+
+func (m *ExpvarMetrics) Processed(block string, latency time.Duration) {
+	b := m.block(block)
+	b.groupsProcessed.Add(1)
+	b.processingNanos.Add(int64(latency))
+
+	bound := "+Inf"
+	if i := latencyBucket(latency); i < len(LatencyBuckets) {
+		bound = strconv.FormatInt(int64(LatencyBuckets[i]), 10)
+	}
+	b.latencyBuckets.Add(bound, 1)
+}
+
+func (m *ExpvarMetrics) ExpressionError(block string, property string) {
+	m.block(block).expressionErrors.Add(property, 1)
+}
+
+func (m *ExpvarMetrics) StateSize(block string, size int) {
+	m.block(block).stateSize.Set(int64(size))
+}
+
+func (m *ExpvarMetrics) SignalsDropped(block string, reason string, n int) {
+	m.block(block).signalsDropped.Add(reason, int64(n))
+}
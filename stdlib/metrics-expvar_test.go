@@ -0,0 +1,40 @@
+package stdlib_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpvarMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	m := stdlib.NewExpvarMetrics("test_expvar_metrics")
+	m.SignalsIn("filter", nio.DefaultTerminal, 3)
+	m.SignalsOut("filter", "true", 2)
+	m.ExpressionError("filter", "conditions[0].expr")
+	m.Processed("filter", 3*time.Millisecond)
+	m.Processed("filter", time.Minute)
+	m.StateSize("filter", 4)
+	m.SignalsDropped("filter", "malformed", 2)
+
+	var published map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(expvar.Get("test_expvar_metrics").String()), &published); err != nil {
+		t.Fatal(err)
+	}
+
+	filter := published["filter"]
+	assert.Equal(map[string]interface{}{"__default_terminal_value": 3.0}, filter["signals_in"])
+	assert.Equal(map[string]interface{}{"true": 2.0}, filter["signals_out"])
+	assert.Equal(map[string]interface{}{"conditions[0].expr": 1.0}, filter["expression_errors"])
+	assert.Equal(2.0, filter["groups_processed"])
+	assert.Equal(float64(3*time.Millisecond+time.Minute), filter["processing_ns"])
+	assert.Equal(map[string]interface{}{"5000000": 1.0, "+Inf": 1.0}, filter["latency_buckets"])
+	assert.Equal(4.0, filter["state_size"])
+	assert.Equal(map[string]interface{}{"malformed": 2.0}, filter["signals_dropped"])
+}
@@ -0,0 +1,216 @@
+package stdlib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+)
+
+// PrometheusMetrics keeps the metrics of blocks in memory and writes them in
+// the Prometheus text exposition format. It serves them over HTTP as well, so
+// it can be mounted as the scrape endpoint of a gateway.
+//
+// Every metric is named after the namespace, and labelled with the block:
+//
+//	<namespace>_signals_in_total{block, terminal}
+//	<namespace>_signals_out_total{block, terminal}
+//	<namespace>_expression_errors_total{block, property}
+//	<namespace>_processing_seconds{block}, a histogram whose count is the
+//	number of groups processed
+//	<namespace>_state_size{block}
+//	<namespace>_signals_dropped_total{block, reason}
+type PrometheusMetrics struct {
+	namespace string
+
+	mutex            sync.Mutex
+	signalsIn        map[promLabels]int64
+	signalsOut       map[promLabels]int64
+	expressionErrors map[promLabels]int64
+	latencies        map[string]*latencyHistogram
+	stateSizes       map[string]int
+	signalsDropped   map[promLabels]int64
+}
+
+// promLabels are the labels of a counter: the block, and the terminal,
+// property or reason it counts.
+type promLabels struct {
+	block string
+	label string
+}
+
+// latencyHistogram counts latencies in LatencyBuckets; the last bucket
+// counts those above every bound.
+type latencyHistogram struct {
+	buckets []int64
+	count   int64
+	sum     time.Duration
+}
+
+func (h *latencyHistogram) observe(latency time.Duration) {
+	if h.buckets == nil {
+		h.buckets = make([]int64, len(LatencyBuckets)+1)
+	}
+	h.buckets[latencyBucket(latency)]++
+	h.count++
+	h.sum += latency
+}
+
+// NewPrometheusMetrics returns empty metrics named after namespace, such as
+// "nio_block".
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	return &PrometheusMetrics{
+		namespace:        namespace,
+		signalsIn:        map[promLabels]int64{},
+		signalsOut:       map[promLabels]int64{},
+		expressionErrors: map[promLabels]int64{},
+		latencies:        map[string]*latencyHistogram{},
+		stateSizes:       map[string]int{},
+		signalsDropped:   map[promLabels]int64{},
+	}
+}
+
+func (m *PrometheusMetrics) SignalsIn(block string, terminal nio.Terminal, n int) {
+	m.mutex.Lock()
+	m.signalsIn[promLabels{block, string(terminal)}] += int64(n)
+	m.mutex.Unlock()
+}
+
+func (m *PrometheusMetrics) SignalsOut(block string, terminal nio.Terminal, n int) {
+	m.mutex.Lock()
+	m.signalsOut[promLabels{block, string(terminal)}] += int64(n)
+	m.mutex.Unlock()
+}
+
+func (m *PrometheusMetrics) Processed(block string, latency time.Duration) {
+	m.mutex.Lock()
+	h, ok := m.latencies[block]
+	if !ok {
+		h = &latencyHistogram{}
+		m.latencies[block] = h
+	}
+	h.observe(latency)
+	m.mutex.Unlock()
+}
+
+func (m *PrometheusMetrics) ExpressionError(block string, property string) {
+	m.mutex.Lock()
+	m.expressionErrors[promLabels{block, property}]++
+	m.mutex.Unlock()
+}
+
+func (m *PrometheusMetrics) StateSize(block string, size int) {
+	m.mutex.Lock()
+	m.stateSizes[block] = size
+	m.mutex.Unlock()
+}
+
+func (m *PrometheusMetrics) SignalsDropped(block string, reason string, n int) {
+	m.mutex.Lock()
+	m.signalsDropped[promLabels{block, reason}] += int64(n)
+	m.mutex.Unlock()
+}
+
+// WriteTo writes the metrics in the Prometheus text format, in a stable order.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	m.mutex.Lock()
+	m.writeCounter(&buf, "signals_in_total", "Signals enqueued on an input terminal.", "terminal", m.signalsIn)
+	m.writeCounter(&buf, "signals_out_total", "Signals emitted on an output terminal.", "terminal", m.signalsOut)
+	m.writeCounter(&buf, "expression_errors_total", "Properties that failed to evaluate against a signal.", "property", m.expressionErrors)
+	m.writeLatencies(&buf)
+	m.writeStateSizes(&buf)
+	m.writeCounter(&buf, "signals_dropped_total", "Signals discarded without being emitted.", "reason", m.signalsDropped)
+	m.mutex.Unlock()
+
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP serves the metrics to a Prometheus scrape.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
+
+func (m *PrometheusMetrics) writeHeader(buf *bytes.Buffer, name, help, metricType string) string {
+	name = m.namespace + "_" + name
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+	return name
+}
+
+func (m *PrometheusMetrics) writeCounter(buf *bytes.Buffer, name, help, label string, values map[promLabels]int64) {
+	if len(values) == 0 {
+		return
+	}
+	name = m.writeHeader(buf, name, help, "counter")
+
+	keys := make([]promLabels, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].block != keys[j].block {
+			return keys[i].block < keys[j].block
+		}
+		return keys[i].label < keys[j].label
+	})
+
+	for _, key := range keys {
+		fmt.Fprintf(buf, "%s{block=%s,%s=%s} %d\n", name, promQuote(key.block), label, promQuote(key.label), values[key])
+	}
+}
+
+func (m *PrometheusMetrics) writeLatencies(buf *bytes.Buffer) {
+	if len(m.latencies) == 0 {
+		return
+	}
+	name := m.writeHeader(buf, "processing_seconds", "Time taken to process a group.", "histogram")
+
+	blocks := make([]string, 0, len(m.latencies))
+	for block := range m.latencies {
+		blocks = append(blocks, block)
+	}
+	sort.Strings(blocks)
+
+	for _, block := range blocks {
+		h := m.latencies[block]
+
+		var cumulative int64
+		for i, bound := range LatencyBuckets {
+			cumulative += h.buckets[i]
+			fmt.Fprintf(buf, "%s_bucket{block=%s,le=\"%g\"} %d\n", name, promQuote(block), bound.Seconds(), cumulative)
+		}
+		fmt.Fprintf(buf, "%s_bucket{block=%s,le=\"+Inf\"} %d\n", name, promQuote(block), h.count)
+		fmt.Fprintf(buf, "%s_sum{block=%s} %g\n", name, promQuote(block), h.sum.Seconds())
+		fmt.Fprintf(buf, "%s_count{block=%s} %d\n", name, promQuote(block), h.count)
+	}
+}
+
+func (m *PrometheusMetrics) writeStateSizes(buf *bytes.Buffer) {
+	if len(m.stateSizes) == 0 {
+		return
+	}
+	name := m.writeHeader(buf, "state_size", "Groups a grouped block holds state for.", "gauge")
+
+	blocks := make([]string, 0, len(m.stateSizes))
+	for block := range m.stateSizes {
+		blocks = append(blocks, block)
+	}
+	sort.Strings(blocks)
+
+	for _, block := range blocks {
+		fmt.Fprintf(buf, "%s{block=%s} %d\n", name, promQuote(block), m.stateSizes[block])
+	}
+}
+
+// promQuote quotes a label value, escaping what the text format requires.
+func promQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
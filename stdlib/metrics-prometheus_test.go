@@ -0,0 +1,81 @@
+package stdlib_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	m := stdlib.NewPrometheusMetrics("nio_block")
+	m.SignalsIn("filter", nio.DefaultTerminal, 3)
+	m.SignalsIn("filter", nio.DefaultTerminal, 2)
+	m.SignalsOut("filter", "true", 4)
+	m.SignalsOut("filter", "false", 1)
+	m.ExpressionError("filter", "conditions[0].expr")
+	m.Processed("filter", 3*time.Millisecond)
+	m.Processed("filter", 20*time.Second)
+	m.StateSize("counter", 7)
+	m.SignalsDropped("replay", "malformed", 2)
+
+	var buf bytes.Buffer
+	_, err := m.WriteTo(&buf)
+	assert.NoError(err)
+	text := buf.String()
+
+	for _, line := range []string{
+		"# TYPE nio_block_signals_in_total counter",
+		`nio_block_signals_in_total{block="filter",terminal="__default_terminal_value"} 5`,
+		`nio_block_signals_out_total{block="filter",terminal="false"} 1`,
+		`nio_block_signals_out_total{block="filter",terminal="true"} 4`,
+		`nio_block_expression_errors_total{block="filter",property="conditions[0].expr"} 1`,
+		"# TYPE nio_block_processing_seconds histogram",
+		`nio_block_processing_seconds_bucket{block="filter",le="0.0025"} 0`,
+		`nio_block_processing_seconds_bucket{block="filter",le="0.005"} 1`,
+		`nio_block_processing_seconds_bucket{block="filter",le="10"} 1`,
+		`nio_block_processing_seconds_bucket{block="filter",le="+Inf"} 2`,
+		`nio_block_processing_seconds_sum{block="filter"} 20.003`,
+		`nio_block_processing_seconds_count{block="filter"} 2`,
+		"# TYPE nio_block_state_size gauge",
+		`nio_block_state_size{block="counter"} 7`,
+		"# TYPE nio_block_signals_dropped_total counter",
+		`nio_block_signals_dropped_total{block="replay",reason="malformed"} 2`,
+	} {
+		assert.Contains(text, line+"\n")
+	}
+
+	// false sorts before true
+	assert.True(strings.Index(text, `terminal="false"`) < strings.Index(text, `terminal="true"`))
+}
+
+func TestPrometheusMetrics_Quoting(t *testing.T) {
+	m := stdlib.NewPrometheusMetrics("nio")
+	m.StateSize("say \"hi\"\n", 1)
+
+	var buf bytes.Buffer
+	m.WriteTo(&buf)
+
+	assert.Contains(t, buf.String(), `nio_state_size{block="say \"hi\"\n"} 1`)
+}
+
+func TestPrometheusMetrics_ServeHTTP(t *testing.T) {
+	assert := assert.New(t)
+
+	m := stdlib.NewPrometheusMetrics("nio")
+	m.SignalsIn("noop", nio.DefaultTerminal, 1)
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(200, w.Code)
+	assert.Contains(w.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(w.Body.String(), `nio_signals_in_total{block="noop",terminal="__default_terminal_value"} 1`)
+}
@@ -0,0 +1,155 @@
+package stdlib
+
+import (
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/mixins"
+)
+
+// Metrics receives the runtime metrics of blocks. Every call names the block
+// reporting it by its configured name, or else by its id or type.
+// Implementations must be safe for concurrent use, as every block reports
+// from its own goroutines.
+type Metrics interface {
+	// SignalsIn counts signals enqueued on an input terminal.
+	SignalsIn(block string, terminal nio.Terminal, n int)
+	// SignalsOut counts signals emitted on an output terminal.
+	SignalsOut(block string, terminal nio.Terminal, n int)
+	// Processed observes how long a block took to process one group: the
+	// signals of one group of a grouped block, or else one signal group.
+	Processed(block string, latency time.Duration)
+	// ExpressionError counts a property that failed to evaluate against a
+	// signal.
+	ExpressionError(block string, property string)
+	// StateSize reports how many groups a grouped block holds state for.
+	StateSize(block string, size int)
+	// SignalsDropped counts signals a block discarded without emitting
+	// them, by the reason they were, such as "malformed" or "queue_full".
+	SignalsDropped(block string, reason string, n int)
+}
+
+// LatencyBuckets are the upper bounds of the buckets processing latencies are
+// counted in.
+var LatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// latencyBucket returns the index of the bucket a latency is counted in, which
+// is len(LatencyBuckets) for latencies above every bound.
+func latencyBucket(latency time.Duration) int {
+	for i, bound := range LatencyBuckets {
+		if latency <= bound {
+			return i
+		}
+	}
+	return len(LatencyBuckets)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) SignalsIn(string, nio.Terminal, int)  {}
+func (nopMetrics) SignalsOut(string, nio.Terminal, int) {}
+func (nopMetrics) Processed(string, time.Duration)      {}
+func (nopMetrics) ExpressionError(string, string)       {}
+func (nopMetrics) StateSize(string, int)                {}
+func (nopMetrics) SignalsDropped(string, string, int)   {}
+
+// NopMetrics discards all metrics. It is the default of every block.
+var NopMetrics Metrics = nopMetrics{}
+
+func metricsOrNop(m Metrics) Metrics {
+	if m == nil {
+		return NopMetrics
+	}
+	return m
+}
+
+// groupFunc processes the signals of one group of a grouped block.
+type groupFunc func(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error
+
+// blockMetrics reports the metrics of one block. Its zero value discards
+// them, so blocks that were never configured can still report.
+type blockMetrics struct {
+	metrics Metrics
+	block   string
+}
+
+func newBlockMetrics(m Metrics, atom nio.BlockConfigAtom) blockMetrics {
+	block := atom.Name
+	if block == "" {
+		block = atom.ID
+	}
+	if block == "" {
+		block = atom.Type
+	}
+	return blockMetrics{metrics: metricsOrNop(m), block: block}
+}
+
+func (m blockMetrics) in(terminal nio.Terminal, signals nio.SignalGroup) {
+	if m.metrics != nil {
+		m.metrics.SignalsIn(m.block, terminal, len(signals))
+	}
+}
+
+func (m blockMetrics) out(terminal nio.Terminal, signals nio.SignalGroup) {
+	if m.metrics != nil {
+		m.metrics.SignalsOut(m.block, terminal, len(signals))
+	}
+}
+
+func (m blockMetrics) expressionError(property string) {
+	if m.metrics != nil {
+		m.metrics.ExpressionError(m.block, property)
+	}
+}
+
+func (m blockMetrics) stateSize(size int) {
+	if m.metrics != nil {
+		m.metrics.StateSize(m.block, size)
+	}
+}
+
+func (m blockMetrics) dropped(reason string, n int) {
+	if m.metrics != nil && n > 0 {
+		m.metrics.SignalsDropped(m.block, reason, n)
+	}
+}
+
+// processed observes the time since start.
+func (m blockMetrics) processed(start time.Time) {
+	if m.metrics != nil {
+		m.metrics.Processed(m.block, time.Since(start))
+	}
+}
+
+// notify counts the signals emitted through a notify func.
+func (m blockMetrics) notify(notify nio.NotifyFunc) nio.NotifyFunc {
+	return func(terminal nio.Terminal, signals nio.SignalGroup) error {
+		m.out(terminal, signals)
+		return notify(terminal, signals)
+	}
+}
+
+// group times the processing of every group by fn.
+func (m blockMetrics) group(fn groupFunc) groupFunc {
+	return func(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error {
+		defer m.processed(time.Now())
+		return fn(group, notify, signals)
+	}
+}
@@ -0,0 +1,186 @@
+package stdlib_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordedMetrics records what blocks report, keyed by block.
+type recordedMetrics struct {
+	mutex            sync.Mutex
+	signalsIn        map[string]map[nio.Terminal]int
+	signalsOut       map[string]map[nio.Terminal]int
+	processed        map[string]int
+	expressionErrors map[string]map[string]int
+	stateSizes       map[string]int
+	signalsDropped   map[string]map[string]int
+}
+
+func newRecordedMetrics() *recordedMetrics {
+	return &recordedMetrics{
+		signalsIn:        map[string]map[nio.Terminal]int{},
+		signalsOut:       map[string]map[nio.Terminal]int{},
+		processed:        map[string]int{},
+		expressionErrors: map[string]map[string]int{},
+		stateSizes:       map[string]int{},
+		signalsDropped:   map[string]map[string]int{},
+	}
+}
+
+func (m *recordedMetrics) SignalsIn(block string, terminal nio.Terminal, n int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.signalsIn[block] == nil {
+		m.signalsIn[block] = map[nio.Terminal]int{}
+	}
+	m.signalsIn[block][terminal] += n
+}
+
+func (m *recordedMetrics) SignalsOut(block string, terminal nio.Terminal, n int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.signalsOut[block] == nil {
+		m.signalsOut[block] = map[nio.Terminal]int{}
+	}
+	m.signalsOut[block][terminal] += n
+}
+
+func (m *recordedMetrics) Processed(block string, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.processed[block]++
+}
+
+func (m *recordedMetrics) ExpressionError(block string, property string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.expressionErrors[block] == nil {
+		m.expressionErrors[block] = map[string]int{}
+	}
+	m.expressionErrors[block][property]++
+}
+
+func (m *recordedMetrics) StateSize(block string, size int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.stateSizes[block] = size
+}
+
+func (m *recordedMetrics) SignalsDropped(block string, reason string, n int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.signalsDropped[block] == nil {
+		m.signalsDropped[block] = map[string]int{}
+	}
+	m.signalsDropped[block][reason] += n
+}
+
+func TestMetrics_Filter(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := newRecordedMetrics()
+	b := stdlib.FilterBlock{Metrics: metrics}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Filter",
+	"name": "positive",
+	"conditions": [{ "expr": "{{ $bool }}" }]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"bool": true}, nio.Signal{"bool": false}, nio.Signal{"bool": 3})
+	takeOne(t, b.ChOutLeft, &b.Busy)
+	takeOne(t, b.ChOutRight, &b.Busy)
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	assert.Equal(map[nio.Terminal]int{nio.DefaultTerminal: 3}, metrics.signalsIn["positive"])
+	assert.Equal(map[nio.Terminal]int{"true": 1, "false": 1}, metrics.signalsOut["positive"])
+	assert.Equal(map[string]int{"conditions[0].expr": 1}, metrics.expressionErrors["positive"])
+	assert.Equal(1, metrics.processed["positive"])
+}
+
+func TestMetrics_Grouped(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := newRecordedMetrics()
+	b := stdlib.CounterBlock{Metrics: metrics}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Counter",
+	"id": "counter-1",
+	"group_by": "{{ $group }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a"}, nio.Signal{"group": "b"}, nio.Signal{"group": "a"})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	// unnamed blocks report by id
+	assert.Equal(map[nio.Terminal]int{nio.DefaultTerminal: 3}, metrics.signalsIn["counter-1"])
+	assert.Equal(map[nio.Terminal]int{nio.DefaultTerminal: 2}, metrics.signalsOut["counter-1"])
+	assert.Equal(2, metrics.processed["counter-1"], "should time each group")
+	assert.Equal(2, metrics.stateSizes["counter-1"])
+}
+
+func TestMetrics_Simulator(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := newRecordedMetrics()
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.IdentityIntervalSimulatorBlock{Clock: clock, Metrics: metrics}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "IdentityIntervalSimulator",
+	"interval": {"seconds": 1},
+	"num_signals": 2
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	settle(t, clock, 1)
+	clock.Advance(time.Second)
+	takeWithin(t, b.ChOut)
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	// unnamed blocks without an id report by type
+	assert.Equal(map[nio.Terminal]int{nio.DefaultTerminal: 2}, metrics.signalsOut["IdentityIntervalSimulator"])
+	assert.Equal(1, metrics.processed["IdentityIntervalSimulator"])
+}
+
+func TestMetrics_DefaultsToNop(t *testing.T) {
+	b := stdlib.NoopBlock{}
+	if err := b.Configure(nio.RawBlockConfig(`{"type": "Noop"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotPanics(t, func() {
+		put(t, &b, nio.DefaultTerminal, nio.Signal{})
+	})
+}
@@ -2,7 +2,8 @@ package stdlib
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/niolabs/gonio-framework"
 	"github.com/niolabs/gonio-framework/props"
@@ -11,64 +12,96 @@ import (
 type ModifierBlock struct {
 	nio.Transformer
 	Config ModifierBlockConfig
+
+	exclude hoistedBool
+	chain   hoistedBool
+	fields  []modifierField
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	metrics blockMetrics
+}
+
+type modifierField struct {
+	title   hoistedString
+	formula hoistedAny
+	action  hoistedString
 }
 
 type ModifierBlockConfig struct {
 	nio.BlockConfigAtom
-	Exclude props.BooleanProperty `json:"exclude"`
+	Exclude props.BooleanProperty  `json:"exclude" title:"Exclude existing fields?" order:"0" default:"false"`
+	Chain   *props.BooleanProperty `json:"chain_fields" title:"Evaluate fields in order?" order:"2" advanced:"true" default:"false"`
 	Fields  []struct {
-		Title   props.StringProperty `json:"title"`
-		Formula *props.AnyProperty   `json:"formula"`
-	} `json:"fields"`
+		Title   props.StringProperty  `json:"title" title:"Attribute Name" order:"0" default:""`
+		Formula *props.AnyProperty    `json:"formula" title:"Attribute Value" order:"1" allow_none:"true" default:""`
+		Action  *props.StringProperty `json:"action" title:"Action" order:"2" advanced:"true" options:"set,delete" default:"set"`
+	} `json:"fields" title:"Fields" order:"1" obj_type:"SignalField"`
 }
 
+const (
+	modifierActionSet    = "set"
+	modifierActionDelete = "delete"
+)
+
 func (b *ModifierBlock) Configure(config nio.RawBlockConfig) error {
 	b.Transformer.Configure()
-	if err := json.Unmarshal(config, &b.Config); err != nil {
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
 		return err
 	}
-	return nil
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
+
+	b.exclude, err = hoistBool(&b.Config.Exclude, c.raw["exclude"], false)
+	c.add("exclude", err)
+
+	b.chain, err = hoistBool(b.Config.Chain, c.raw["chain_fields"], false)
+	c.add("chain_fields", err)
+
+	rawFields, err := rawPropertyList(c.raw["fields"])
+	if err != nil {
+		// decodeConfig has reported the malformed list already
+		return c.err()
+	}
+
+	b.fields = make([]modifierField, len(b.Config.Fields))
+	for i := range b.Config.Fields {
+		field, rawField := &b.Config.Fields[i], rawFields[i]
+
+		b.fields[i].title, err = hoistString(&field.Title, rawField["title"], "")
+		c.add(fmt.Sprintf("fields[%d].title", i), err)
+
+		b.fields[i].formula, err = hoistAny(field.Formula, rawField["formula"], nil)
+		c.add(fmt.Sprintf("fields[%d].formula", i), err)
+
+		b.fields[i].action, err = hoistString(field.Action, rawField["action"], modifierActionSet)
+		if c.add(fmt.Sprintf("fields[%d].action", i), err) && b.fields[i].action.constant {
+			switch action := b.fields[i].action.value; action {
+			case modifierActionSet, modifierActionDelete:
+			default:
+				c.addf(fmt.Sprintf("fields[%d].action", i), "invalid action `%s'", action)
+			}
+		}
+	}
+
+	return c.err()
 }
 
 func (b *ModifierBlock) Start(ctx context.Context) {
 	for {
 		select {
 		case inSignals := <-b.ChIn:
+			start := time.Now()
 			var outSignals nio.SignalGroup
 
 			for _, inSignal := range inSignals {
-				next := nio.Signal{}
-
-				if exclude, excludeErr := b.Config.Exclude.InvokeDefault(inSignal, false); excludeErr != nil {
-					// TODO handle error
-					next = inSignal
-					goto appendSignal
-				} else if exclude {
-					next = nio.Signal{}
-				} else {
-					next = inSignal.Clone()
-				}
-
-				for _, field := range b.Config.Fields {
-					key, keyErr := field.Title.Invoke(inSignal)
-					if keyErr != nil {
-						// TODO handle error
-						goto appendSignal
-					}
-
-					value, valueErr := field.Formula.InvokeDefault(inSignal, nil)
-					if valueErr != nil {
-						// TODO handle error
-						goto appendSignal
-					}
-
-					next[key] = value
-				}
-
-			appendSignal:
-				outSignals = append(outSignals, next)
+				outSignals = append(outSignals, b.modify(inSignal))
 			}
 
+			b.metrics.processed(start)
+			b.metrics.out(b.TOut, outSignals)
 			b.ChOut <- outSignals
 			b.Busy.Done()
 		case <-ctx.Done():
@@ -77,14 +110,93 @@ func (b *ModifierBlock) Start(ctx context.Context) {
 	}
 }
 
+// modify applies the configured fields to a single signal. Titles are dotted
+// attribute paths. When fields are chained, each title and formula sees the
+// result of the fields before it rather than the original signal. A field
+// whose title, action or formula fails to evaluate is skipped and counted as
+// an expression error, and the fields after it still apply.
+func (b *ModifierBlock) modify(inSignal nio.Signal) nio.Signal {
+	var next nio.Signal
+
+	exclude, excludeErr := b.exclude.Invoke(inSignal)
+	if excludeErr != nil {
+		b.metrics.expressionError("exclude")
+		return inSignal
+	} else if exclude {
+		next = nio.Signal{}
+	} else {
+		next = inSignal.Clone()
+	}
+
+	chain, chainErr := b.chain.Invoke(inSignal)
+	if chainErr != nil {
+		b.metrics.expressionError("chain_fields")
+		return inSignal
+	}
+
+	// scope is the signal that titles and formulas are evaluated against
+	scope := inSignal
+	switch {
+	case chain && exclude:
+		scope = inSignal.Clone()
+	case chain:
+		scope = next
+	}
+	// when excluding, writes must land in both the output and the scope
+	mirror := chain && exclude
+
+	for i := range b.fields {
+		field := &b.fields[i]
+
+		key, keyErr := field.title.Invoke(scope)
+		if keyErr != nil {
+			b.metrics.expressionError(fmt.Sprintf("fields[%d].title", i))
+			continue
+		}
+
+		action, actionErr := field.action.Invoke(scope)
+		if actionErr != nil {
+			b.metrics.expressionError(fmt.Sprintf("fields[%d].action", i))
+			continue
+		}
+
+		switch action {
+		case modifierActionSet:
+			value, valueErr := field.formula.Invoke(scope)
+			if valueErr != nil {
+				b.metrics.expressionError(fmt.Sprintf("fields[%d].formula", i))
+				continue
+			}
+
+			setPath(next, key, value)
+			if mirror {
+				setPath(scope, key, value)
+			}
+		case modifierActionDelete:
+			deletePath(next, key)
+			if mirror {
+				deletePath(scope, key)
+			}
+		default:
+			// actions that depend on the signal can only be checked here
+			b.metrics.expressionError(fmt.Sprintf("fields[%d].action", i))
+		}
+	}
+
+	return next
+}
+
 func (b *ModifierBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
 	return b.Transformer.Enqueue(terminal, signals, 1)
 }
 
+const modifierVersion = "1.2.0"
+
 var Modifier = nio.BlockTypeEntry{
 	Create: func() nio.Block { return &ModifierBlock{} },
 	Definition: nio.BlockTypeDefinition{
-		Version: "1.1.0",
+		Version: modifierVersion,
 		BlockAttributes: nio.BlockAttributes{
 			Outputs: []nio.TerminalDefinition{
 				{
@@ -107,105 +219,9 @@ var Modifier = nio.BlockTypeEntry{
 				},
 			},
 		},
-		Namespace: "blocks.modifier.modifier_block.Modifier",
-		Properties: map[nio.Property]nio.PropertyDefinition{
-			"type": {
-				"order":      nil,
-				"advanced":   false,
-				"visible":    false,
-				"title":      "Type",
-				"type":       "StringType",
-				"readonly":   true,
-				"allow_none": false,
-				"default":    nil,
-			},
-			"exclude": {
-				"order":      0,
-				"type":       "BoolType",
-				"advanced":   false,
-				"visible":    true,
-				"default":    false,
-				"allow_none": false,
-				"title":      "Exclude existing fields?",
-			},
-			"version": {
-				"order":      nil,
-				"type":       "StringType",
-				"advanced":   true,
-				"visible":    true,
-				"default":    "1.1.0",
-				"allow_none": false,
-				"title":      "Version",
-			},
-			"fields": {
-				"order":         1,
-				"advanced":      false,
-				"visible":       true,
-				"list_obj_type": "ObjectType",
-				"title":         "Fields",
-				"type":          "ListType",
-				"obj_type":      "SignalField",
-				"allow_none":    false,
-				"template": map[string]interface{}{
-					"title": map[string]interface{}{
-						"order":      0,
-						"type":       "Type",
-						"advanced":   false,
-						"visible":    true,
-						"default":    "",
-						"allow_none": false,
-						"title":      "Attribute Name",
-					},
-					"formula": map[string]interface{}{
-						"order":      1,
-						"type":       "Type",
-						"advanced":   false,
-						"visible":    true,
-						"default":    "",
-						"allow_none": true,
-						"title":      "Attribute Value",
-					},
-				},
-				"default": []interface{}{},
-			},
-			"id": {
-				"order":      nil,
-				"type":       "StringType",
-				"advanced":   false,
-				"visible":    false,
-				"default":    nil,
-				"allow_none": false,
-				"title":      "Id",
-			},
-			"name": {
-				"order":      nil,
-				"type":       "StringType",
-				"advanced":   false,
-				"visible":    false,
-				"default":    nil,
-				"allow_none": true,
-				"title":      "Name",
-			},
-			"log_level": {
-				"order": nil,
-				"options": map[string]int{
-					"WARNING":  30,
-					"NOTSET":   0,
-					"ERROR":    40,
-					"INFO":     20,
-					"DEBUG":    10,
-					"CRITICAL": 50,
-				},
-				"advanced":   true,
-				"visible":    true,
-				"title":      "Log Level",
-				"type":       "SelectType",
-				"enum":       "LogLevel",
-				"allow_none": false,
-				"default":    "NOTSET",
-			},
-		},
-		Commands: map[nio.Command]nio.CommandDefinition{},
-		Name:     "Modifier",
+		Namespace:  "blocks.modifier.modifier_block.Modifier",
+		Properties: definitionProperties(ModifierBlockConfig{}, modifierVersion),
+		Commands:   map[nio.Command]nio.CommandDefinition{},
+		Name:       "Modifier",
 	},
 }
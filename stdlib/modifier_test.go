@@ -144,3 +144,227 @@ func TestModifierBlock_Exclude(t *testing.T) {
 		nio.Signal{"sum": 10.0},
 	}, signals)
 }
+
+func TestModifierBlock_NestedTitle(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := &ModifierBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Modifier",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"fields": [
+		{ "title": "pos.x", "formula": 1 },
+		{ "title": "meta.source.name", "formula": "sensor" }
+	]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	pos := map[string]interface{}{"z": 3}
+	put(t, b, nio.DefaultTerminal, nio.Signal{"pos": pos})
+	signals := takeOne(t, b.ChOut, &b.Busy)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{
+			"pos": map[string]interface{}{"x": 1.0, "z": 3},
+			"meta": map[string]interface{}{
+				"source": map[string]interface{}{"name": "sensor"},
+			},
+		},
+	}, signals)
+	assert.EqualValues(map[string]interface{}{"z": 3}, pos, "input should be untouched")
+}
+
+func TestModifierBlock_Delete(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := &ModifierBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Modifier",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"fields": [
+		{ "title": "a", "action": "delete" },
+		{ "title": "pos.y", "action": "delete" },
+		{ "title": "missing.path", "action": "delete" }
+	]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, b, nio.DefaultTerminal, nio.Signal{
+		"a":   1.0,
+		"b":   2.0,
+		"pos": map[string]interface{}{"x": 1.0, "y": 2.0},
+	})
+	signals := takeOne(t, b.ChOut, &b.Busy)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"b": 2.0, "pos": map[string]interface{}{"x": 1.0}},
+	}, signals)
+}
+
+func TestModifierBlock_Chain(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := &ModifierBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Modifier",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"chain_fields": true,
+	"fields": [
+		{ "title": "sum", "formula": "{{ $a + $b }}" },
+		{ "title": "double", "formula": "{{ $sum * 2 }}" },
+		{ "title": "a", "action": "delete" }
+	]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, b, nio.DefaultTerminal, nio.Signal{"a": 5.0, "b": 3.0})
+	signals := takeOne(t, b.ChOut, &b.Busy)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"b": 3.0, "sum": 8.0, "double": 16.0},
+	}, signals)
+}
+
+func TestModifierBlock_ChainExclude(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := &ModifierBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Modifier",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"exclude": true,
+	"chain_fields": true,
+	"fields": [
+		{ "title": "sum", "formula": "{{ $a + $b }}" },
+		{ "title": "double", "formula": "{{ $sum * 2 }}" }
+	]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, b, nio.DefaultTerminal, nio.Signal{"a": 5.0, "b": 3.0})
+	signals := takeOne(t, b.ChOut, &b.Busy)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"sum": 8.0, "double": 16.0},
+	}, signals)
+}
+
+func TestModifierBlock_InvalidAction(t *testing.T) {
+	assert := assert.New(t)
+
+	b := &ModifierBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Modifier",
+	"fields": [
+		{ "title": "a", "action": "delete" },
+		{ "title": "b", "action": "rename" }
+	]
+}`))
+	assert.EqualError(err, "Modifier: fields[1].action: invalid action `rename'")
+}
+
+func TestModifierBlock_DynamicAction(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := newRecordedMetrics()
+	b := &ModifierBlock{Metrics: metrics}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Modifier",
+	"fields": [
+		{ "title": "a", "action": "{{ $action }}" },
+		{ "title": "b", "formula": "{{ $a }}" }
+	]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	// a field with an invalid action is skipped, and the fields after it
+	// still apply
+	put(t, b, nio.DefaultTerminal,
+		nio.Signal{"action": "delete", "a": 1.0},
+		nio.Signal{"action": "rename", "a": 2.0},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"action": "delete", "b": 1.0},
+		nio.Signal{"action": "rename", "a": 2.0, "b": 2.0},
+	}, takeOne(t, b.ChOut, &b.Busy))
+	assert.Equal(map[string]int{"fields[0].action": 1}, metrics.expressionErrors["Modifier"])
+}
+
+func TestModifierBlock_FormulaError(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := newRecordedMetrics()
+	b := &ModifierBlock{Metrics: metrics}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Modifier",
+	"fields": [
+		{ "title": "a", "formula": "{{ $n }}" },
+		{ "title": "b", "formula": "{{ $n > 0 }}" },
+		{ "title": "c", "formula": "done" }
+	]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	// a field whose formula fails is skipped, and the fields after it
+	// still apply
+	put(t, b, nio.DefaultTerminal, nio.Signal{"n": "x"}, nio.Signal{"n": 1})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"n": "x", "a": "x", "c": "done"},
+		nio.Signal{"n": 1, "a": 1, "b": true, "c": "done"},
+	}, takeOne(t, b.ChOut, &b.Busy))
+	assert.Equal(map[string]int{"fields[1].formula": 1}, metrics.expressionErrors["Modifier"])
+}
@@ -2,7 +2,6 @@ package stdlib
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/niolabs/gonio-framework"
 )
@@ -11,17 +10,31 @@ import (
 type NoopBlock struct {
 	nio.Transformer
 	Config nio.BlockConfigAtom
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	metrics blockMetrics
 }
 
 func (nb *NoopBlock) Configure(config nio.RawBlockConfig) error {
 	nb.Transformer.Configure()
-	return json.Unmarshal(config, &nb.Config)
+
+	c, err := decodeConfig(config, &nb.Config)
+	if err != nil {
+		return err
+	}
+	nb.metrics = newBlockMetrics(nb.Metrics, c.atom)
+
+	return c.err()
 }
 
 func (nb *NoopBlock) Start(ctx context.Context) {
 	for {
 		select {
 		case signals := <-nb.ChIn:
+			nb.metrics.out(nb.TOut, signals)
 			nb.ChOut <- signals
 			nb.Busy.Done()
 		case <-ctx.Done():
@@ -31,57 +44,22 @@ func (nb *NoopBlock) Start(ctx context.Context) {
 }
 
 func (nb *NoopBlock) Enqueue(t nio.Terminal, sg nio.SignalGroup) error {
+	nb.metrics.in(t, sg)
 	return nb.Consumer.Enqueue(t, sg, 1)
 }
 
 func newNoopBlock() nio.Block { return &NoopBlock{} }
 
+const noopVersion = "0.0.0"
+
 var Noop = nio.BlockTypeEntry{
 	Create: newNoopBlock,
 	Definition: nio.BlockTypeDefinition{
-		Namespace: "goblocks.noop",
-		Commands:  map[nio.Command]nio.CommandDefinition{},
-		Version:   "0.0.0",
-		Name:      "Noop",
-		Properties: map[nio.Property]nio.PropertyDefinition{
-			"id": {
-				"title":      "Id",
-				"order":      nil,
-				"type":       "StringType",
-				"default":    nil,
-				"advanced":   false,
-				"allow_none": false,
-				"visible":    false,
-			},
-			"type": {
-				"title":      "Type",
-				"readonly":   true,
-				"visible":    false,
-				"advanced":   false,
-				"allow_none": false,
-				"order":      nil,
-				"type":       "StringType",
-				"default":    nil,
-			},
-			"name": {
-				"title":      "Name",
-				"order":      nil,
-				"type":       "StringType",
-				"default":    nil,
-				"advanced":   false,
-				"allow_none": true,
-				"visible":    false,
-			},
-			"version": {
-				"title":      "Version",
-				"order":      nil,
-				"type":       "StringType",
-				"default":    "1.2.0",
-				"advanced":   true,
-				"allow_none": false,
-				"visible":    true,
-			},
-		},
+		Namespace:  "goblocks.noop",
+		Commands:   map[nio.Command]nio.CommandDefinition{},
+		Version:    noopVersion,
+		Name:       "Noop",
+		Properties: definitionProperties(nio.BlockConfigAtom{}, noopVersion),
 		BlockAttributes: nio.BlockAttributes{
 			Inputs: []nio.TerminalDefinition{
 				{
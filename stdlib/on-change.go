@@ -0,0 +1,238 @@
+package stdlib
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/mixins"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// OnChangeBlock passes a signal only when its value differs from the last
+// value passed for its group; the first signal of a group always passes.
+// Numeric values within deadband of the last value passed are not a change,
+// so a value drifting slowly passes once it has drifted further than the
+// deadband in all.
+//
+// When old_value_name or new_value_name are set, passed signals are copied
+// with the last value passed, which is null for the first signal of a group,
+// and their own value.
+type OnChangeBlock struct {
+	nio.Transformer
+	mixins.GroupByMixin
+	Config OnChangeBlockConfig
+
+	// Clock expires groups. It defaults to RealClock.
+	Clock Clock
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	value    hoistedAny
+	deadband float64
+	oldKey   string
+	newKey   string
+
+	mutex   sync.Mutex
+	values  groupState
+	metrics blockMetrics
+}
+
+type OnChangeBlockConfig struct {
+	nio.BlockConfigAtom
+	Value        *props.AnyProperty    `json:"value" order:"0"`
+	Deadband     *props.AnyProperty    `json:"deadband" order:"1" type:"FloatType" default:"0"`
+	OldValueName *props.StringProperty `json:"old_value_name" order:"2" advanced:"true" default:""`
+	NewValueName *props.StringProperty `json:"new_value_name" order:"3" advanced:"true" default:""`
+	GroupStateConfig
+}
+
+func (b *OnChangeBlock) Configure(config nio.RawBlockConfig) error {
+	b.Transformer.Configure()
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
+
+	c.add("group_by", b.GroupByMixin.Configure(config, b.metrics.notify(b.Notify)))
+
+	if b.Config.Value == nil {
+		c.addf("value", "value is unset")
+	} else {
+		b.value, err = hoistAny(b.Config.Value, c.raw["value"], nil)
+		c.add("value", err)
+	}
+
+	if c.add("deadband", assignFloatDefault(b.Config.Deadband, &b.deadband, 0)) && b.deadband < 0 {
+		c.addf("deadband", "deadband must not be negative")
+	}
+	c.add("old_value_name", b.Config.OldValueName.AssignToDefault(&b.oldKey, nil, ""))
+	c.add("new_value_name", b.Config.NewValueName.AssignToDefault(&b.newKey, nil, ""))
+
+	b.values.configure(c, &b.Config.GroupStateConfig, b.Clock, &b.mutex, b.metrics)
+	b.values.addGroup = b.GroupByMixin.AddGroupToSignal
+
+	return c.err()
+}
+
+func (b *OnChangeBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
+	return b.Transformer.Enqueue(terminal, signals, 1)
+}
+
+func (b *OnChangeBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	b.Transformer.EachOutput(fn)
+	b.values.eachOutput(fn)
+}
+
+func (b *OnChangeBlock) Start(ctx context.Context) {
+	defer b.values.stop()
+
+	for {
+		select {
+		case signals := <-b.ChIn:
+			b.GroupByMixin.Process(signals, b.metrics.group(b.process))
+			b.Busy.Done()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *OnChangeBlock) process(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error {
+	b.mutex.Lock()
+
+	last, seen := b.values.get(group)
+
+	var changed nio.SignalGroup
+	for _, signal := range signals {
+		value, err := b.value.Invoke(signal)
+		if err != nil {
+			b.metrics.expressionError("value")
+			continue
+		}
+
+		if seen && !b.changed(last, value) {
+			continue
+		}
+
+		changed = append(changed, b.withValues(signal, last, value))
+		last, seen = value, true
+	}
+
+	if seen {
+		b.values.set(group, last)
+	}
+	b.mutex.Unlock()
+
+	if len(changed) == 0 {
+		return nil
+	}
+	return notify(b.TOut, changed)
+}
+
+// changed reports whether value differs from the last value passed. Numbers
+// are compared by value, whatever their type, allowing for the deadband.
+func (b *OnChangeBlock) changed(last, value interface{}) bool {
+	l, lok := toFloat(last)
+	v, vok := toFloat(value)
+	if lok && vok {
+		return math.Abs(v-l) > b.deadband
+	}
+	return !reflect.DeepEqual(last, value)
+}
+
+// withValues copies a passed signal with its old and new values, if either
+// is asked for.
+func (b *OnChangeBlock) withValues(signal nio.Signal, last, value interface{}) nio.Signal {
+	if b.oldKey == "" && b.newKey == "" {
+		return signal
+	}
+
+	fields := nio.Signal{}
+	if b.oldKey != "" {
+		fields[b.oldKey] = last
+	}
+	if b.newKey != "" {
+		fields[b.newKey] = value
+	}
+	return signal.CloneWith(fields)
+}
+
+// Command inspects or resets the values of the groups. values returns the
+// last value passed for every group; reset forgets the group given, or every
+// group, so its next signal passes, and returns the values left.
+func (b *OnChangeBlock) Command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch command {
+	case "values":
+	case "reset":
+		if group, ok := groupArg(args); ok {
+			b.values.delete(group)
+		} else {
+			b.values.clear()
+		}
+	default:
+		return nil, unknownCommand(command)
+	}
+
+	values := map[string]interface{}{}
+	b.values.each(func(group mixins.Group, value interface{}) {
+		values[string(group)] = value
+	})
+	return values, nil
+}
+
+const onChangeVersion = "0.1.0"
+
+var OnChange = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &OnChangeBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.on_change.on_change_block.OnChange",
+		Version:    onChangeVersion,
+		Name:       "OnChange",
+		Properties: groupedProperties(OnChangeBlockConfig{}, onChangeVersion),
+		Commands: map[nio.Command]nio.CommandDefinition{
+			"values": {
+				"title":  "Values",
+				"params": map[string]interface{}{},
+			},
+			"reset": {
+				"title": "Reset",
+				"params": map[string]interface{}{
+					"group": groupParam(true),
+				},
+			},
+		},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+				groupEvictedOutput(1),
+			},
+		},
+	},
+}
@@ -0,0 +1,141 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnChangeBlock_Basic(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.OnChangeBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "OnChange",
+	"value": "{{ $state }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"state": "on", "n": 1},
+		nio.Signal{"state": "on", "n": 2},
+		nio.Signal{"state": "off", "n": 3},
+		nio.Signal{"state": "off", "n": 4},
+		nio.Signal{"state": "on", "n": 5},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"state": "on", "n": 1},
+		nio.Signal{"state": "off", "n": 3},
+		nio.Signal{"state": "on", "n": 5},
+	}, takeOne(t, b.ChOut, &b.Busy))
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"state": "on", "n": 6})
+	takeNone(t, b.ChOut, &b.Busy)
+}
+
+func TestOnChangeBlock_Deadband(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.OnChangeBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "OnChange",
+	"value": "{{ $temperature }}",
+	"deadband": 0.5,
+	"old_value_name": "old",
+	"new_value_name": "new",
+	"group_by": "{{ $sensor }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	// a slow drift passes once it has gone beyond the deadband in all
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"sensor": "a", "temperature": 20.0},
+		nio.Signal{"sensor": "a", "temperature": 20.3},
+		nio.Signal{"sensor": "a", "temperature": 20.5},
+		nio.Signal{"sensor": "a", "temperature": 20.6},
+		nio.Signal{"sensor": "a", "temperature": 20.8},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"sensor": "a", "temperature": 20.0, "old": nil, "new": 20.0},
+		nio.Signal{"sensor": "a", "temperature": 20.6, "old": 20.0, "new": 20.6},
+	}, takeOne(t, b.ChOut, &b.Busy))
+
+	// numbers of other types compare by value
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"sensor": "b", "temperature": 20},
+		nio.Signal{"sensor": "b", "temperature": 20.0},
+		nio.Signal{"sensor": "a", "temperature": 20},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"sensor": "b", "temperature": 20, "old": nil, "new": 20},
+		nio.Signal{"sensor": "a", "temperature": 20, "old": 20.6, "new": 20},
+	}, takeOne(t, b.ChOut, &b.Busy))
+}
+
+func TestOnChangeBlock_Commands(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.OnChangeBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "OnChange",
+	"value": "{{ $state }}",
+	"group_by": "{{ $group }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a", "state": 1}, nio.Signal{"group": "b", "state": 2})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	values, err := b.Command("values", nil)
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{"a": 1, "b": 2}, values)
+
+	// a reset group passes its next signal
+	values, err = b.Command("reset", map[string]interface{}{"group": "a"})
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{"b": 2}, values)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a", "state": 1}, nio.Signal{"group": "b", "state": 2})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "a", "state": 1},
+	}, takeOne(t, b.ChOut, &b.Busy))
+	takeNone(t, b.ChOut, &b.Busy)
+
+	_, err = b.Command("flush", nil)
+	assert.Error(err)
+}
+
+func TestOnChangeBlock_ConfigErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.OnChangeBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "OnChange",
+	"deadband": -1
+}`))
+	assert.EqualError(err, "OnChange: value: value is unset; OnChange: deadband: deadband must not be negative")
+}
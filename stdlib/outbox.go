@@ -0,0 +1,106 @@
+package stdlib
+
+import (
+	"sync"
+
+	"github.com/niolabs/gonio-framework"
+)
+
+// outbox sends the signals a block emits from its timers, in the order they
+// were added, without the block holding its mutex while an output waits to
+// be read. Signals are added with the mutex held, so that they are in the
+// order of the state they came from, and sent by flush once it is released.
+// Only one flush sends at a time; any other returns at once, leaving what it
+// added to the one sending.
+//
+// Once the outbox is closed, as the block stops, signals are only sent if
+// their output has room, and are otherwise dropped and counted as such.
+type outbox struct {
+	metrics blockMetrics
+
+	mutex   sync.Mutex
+	pending []outboxSignals
+	sending bool
+	done    chan struct{}
+}
+
+type outboxSignals struct {
+	terminal nio.Terminal
+	ch       chan<- nio.SignalGroup
+	signals  nio.SignalGroup
+}
+
+// configure empties the outbox and opens it.
+func (o *outbox) configure(metrics blockMetrics) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.metrics = metrics
+	o.pending = nil
+	o.done = make(chan struct{})
+}
+
+// add queues signals to be sent on the output of terminal. It must be called
+// with the block's mutex held.
+func (o *outbox) add(terminal nio.Terminal, ch chan<- nio.SignalGroup, signals nio.SignalGroup) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.pending = append(o.pending, outboxSignals{terminal: terminal, ch: ch, signals: signals})
+}
+
+// flush sends the signals added, unless another flush is sending them
+// already. It must be called without the block's mutex held.
+func (o *outbox) flush() {
+	o.mutex.Lock()
+	if o.sending {
+		o.mutex.Unlock()
+		return
+	}
+	o.sending = true
+
+	for len(o.pending) > 0 {
+		next := o.pending[0]
+		o.pending = o.pending[1:]
+		done := o.done
+
+		o.mutex.Unlock()
+		o.send(next, done)
+		o.mutex.Lock()
+	}
+
+	o.sending = false
+	o.mutex.Unlock()
+}
+
+func (o *outbox) send(s outboxSignals, done <-chan struct{}) {
+	// an output with room takes the signals even once the outbox is
+	// closed
+	select {
+	case s.ch <- s.signals:
+		o.metrics.out(s.terminal, s.signals)
+		return
+	default:
+	}
+
+	select {
+	case s.ch <- s.signals:
+		o.metrics.out(s.terminal, s.signals)
+	case <-done:
+		o.metrics.dropped("stopped", len(s.signals))
+	}
+}
+
+// close stops flushes from waiting for outputs to be read.
+func (o *outbox) close() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	select {
+	case <-o.done:
+	default:
+		if o.done != nil {
+			close(o.done)
+		}
+	}
+}
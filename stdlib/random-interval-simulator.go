@@ -0,0 +1,155 @@
+package stdlib
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// RandomIntervalSimulatorBlock emits random values drawn from a uniform or
+// gaussian distribution.
+type RandomIntervalSimulatorBlock struct {
+	nio.Producer
+	Config RandomIntervalSimulatorConfig
+
+	// Clock paces the simulation. It defaults to RealClock.
+	Clock Clock
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	sim  simulatorEngine
+	rand *rand.Rand
+
+	key          string
+	distribution string
+	min, max     float64
+	mean, stddev float64
+}
+
+type RandomIntervalSimulatorConfig struct {
+	nio.BlockConfigAtom
+	IntervalSimulatorConfig
+
+	Key          *props.StringProperty `json:"attr_name" title:"Attribute Name" order:"1" default:"sim"`
+	Distribution *props.StringProperty `json:"distribution" title:"Distribution" order:"2" options:"uniform,gaussian" default:"uniform"`
+	Min          *props.AnyProperty    `json:"min" title:"Minimum (uniform)" order:"3" type:"FloatType" default:"0"`
+	Max          *props.AnyProperty    `json:"max" title:"Maximum (uniform)" order:"4" type:"FloatType" default:"1"`
+	Mean         *props.AnyProperty    `json:"mean" title:"Mean (gaussian)" order:"5" type:"FloatType" default:"0"`
+	StdDev       *props.AnyProperty    `json:"stddev" title:"Standard Deviation (gaussian)" order:"6" type:"FloatType" default:"1"`
+	Seed         *props.IntProperty    `json:"seed" title:"Random Seed" order:"7" advanced:"true" allow_none:"true"`
+}
+
+const (
+	distributionUniform  = "uniform"
+	distributionGaussian = "gaussian"
+)
+
+func (b *RandomIntervalSimulatorBlock) Configure(config nio.RawBlockConfig) error {
+	b.Producer.Configure()
+
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+
+	b.sim.configure(c, &b.Config.IntervalSimulatorConfig, b.Clock, b.Metrics)
+
+	c.add("attr_name", b.Config.Key.AssignToDefault(&b.key, nil, "sim"))
+
+	if c.add("distribution", b.Config.Distribution.AssignToDefault(&b.distribution, nil, distributionUniform)) {
+		switch b.distribution {
+		case distributionUniform, distributionGaussian:
+		default:
+			c.addf("distribution", "invalid distribution `%s'", b.distribution)
+		}
+	}
+
+	assigned := map[string]bool{}
+	for _, p := range []struct {
+		name         string
+		prop         *props.AnyProperty
+		dst          *float64
+		defaultValue float64
+	}{
+		{"min", b.Config.Min, &b.min, 0},
+		{"max", b.Config.Max, &b.max, 1},
+		{"mean", b.Config.Mean, &b.mean, 0},
+		{"stddev", b.Config.StdDev, &b.stddev, 1},
+	} {
+		assigned[p.name] = c.add(p.name, assignFloatDefault(p.prop, p.dst, p.defaultValue))
+	}
+	if assigned["min"] && assigned["max"] && b.min > b.max {
+		c.addf("max", "max must not be less than min")
+	}
+	if assigned["stddev"] && b.stddev < 0 {
+		c.addf("stddev", "stddev must not be negative")
+	}
+
+	// an unset seed gives a different sequence on every run
+	var seed int64
+	c.add("seed", b.Config.Seed.AssignToDefault(&seed, nil, time.Now().UnixNano()))
+	b.rand = rand.New(rand.NewSource(seed))
+
+	return c.err()
+}
+
+func (b *RandomIntervalSimulatorBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	if b.sim.enqueue(terminal, signals) {
+		return nil
+	}
+	return b.NoEnqueue(terminal)
+}
+
+func (b *RandomIntervalSimulatorBlock) Command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	return b.sim.command(command, args)
+}
+
+func (b *RandomIntervalSimulatorBlock) Start(ctx context.Context) {
+	b.sim.run(ctx, b.TOut, b.ChOut, b.generate)
+}
+
+func (b *RandomIntervalSimulatorBlock) generate(num int64) (nio.SignalGroup, bool) {
+	signals := make(nio.SignalGroup, num)
+	for i := range signals {
+		var value float64
+		switch b.distribution {
+		case distributionGaussian:
+			value = b.rand.NormFloat64()*b.stddev + b.mean
+		default:
+			value = b.min + b.rand.Float64()*(b.max-b.min)
+		}
+		signals[i] = nio.Signal{b.key: value}
+	}
+	return signals, false
+}
+
+const randomIntervalSimulatorVersion = "0.1.0"
+
+var RandomIntervalSimulator = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &RandomIntervalSimulatorBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Version: randomIntervalSimulatorVersion,
+		BlockAttributes: nio.BlockAttributes{
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Inputs: simulatorInputs,
+		},
+		Namespace:  "goblocks.simulator.blocks.RandomIntervalSimulator",
+		Properties: definitionProperties(RandomIntervalSimulatorConfig{}, randomIntervalSimulatorVersion),
+		Commands:   simulatorCommands,
+		Name:       "RandomIntervalSimulator",
+	},
+}
@@ -0,0 +1,338 @@
+package stdlib
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/mixins"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// RateLimitBlock passes signals at no more than rate signals per interval
+// for each group, allowing bursts of up to burst signals. It keeps a token
+// bucket for every group, holding burst tokens, refilled at the rate, of
+// which every signal passed takes one. Signals that find the bucket empty
+// overflow, and are dropped, queued until tokens refill, or emitted on the
+// overflow output, as the overflow property says. Signals dropped, signals
+// beyond the queue size, and those still queued when their group is evicted
+// or the block stops, are counted as dropped. A group_ttl must outlast the
+// time the bucket takes to refill.
+type RateLimitBlock struct {
+	nio.Splitter
+	mixins.GroupByMixin
+	Config RateLimitBlockConfig
+
+	// Clock refills the buckets. It defaults to RealClock.
+	Clock Clock
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	rate      float64
+	interval  time.Duration
+	burst     int64
+	overflow  string
+	queueSize int64
+
+	mutex   sync.Mutex
+	buckets groupState
+	metrics blockMetrics
+	outbox  outbox
+}
+
+type RateLimitBlockConfig struct {
+	nio.BlockConfigAtom
+	Rate      *props.AnyProperty       `json:"rate" title:"Signals per Interval" order:"0" type:"FloatType" default:"1"`
+	Interval  *props.TimeDeltaProperty `json:"interval" order:"1" default:"{\"seconds\": 1}"`
+	Burst     *props.IntProperty       `json:"burst" title:"Burst Size" order:"2" default:"1"`
+	Overflow  *props.StringProperty    `json:"overflow" order:"3" options:"drop,queue,overflow" default:"drop"`
+	QueueSize *props.IntProperty       `json:"queue_size" title:"Queue Size" order:"4" advanced:"true" default:"100"`
+	GroupStateConfig
+}
+
+// What becomes of signals that find the bucket of their group empty.
+const (
+	rateLimitOverflowDrop  = "drop"
+	rateLimitOverflowQueue = "queue"
+	rateLimitOverflowEmit  = "overflow"
+)
+
+// rateLimitBucket is the token bucket of a group, and the signals it has
+// queued.
+type rateLimitBucket struct {
+	tokens  float64
+	updated time.Time
+	queue   nio.SignalGroup
+	timer   Timer
+}
+
+func (b *RateLimitBlock) Configure(config nio.RawBlockConfig) error {
+	SetTerminal(&b.TOutLeft, nio.DefaultTerminal)
+	SetTerminal(&b.TOutRight, "overflow")
+	b.Splitter.Configure()
+
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
+
+	c.add("group_by", b.GroupByMixin.Configure(config, b.notify))
+
+	if c.add("rate", assignFloatDefault(b.Config.Rate, &b.rate, 1)) && b.rate <= 0 {
+		c.addf("rate", "rate must be positive")
+	}
+	if c.add("interval", b.Config.Interval.AssignToDefault(&b.interval, nil, time.Second)) && b.interval <= 0 {
+		c.addf("interval", "interval must be positive")
+	}
+	if c.add("burst", b.Config.Burst.AssignToDefault(&b.burst, nil, 1)) && b.burst < 1 {
+		c.addf("burst", "burst must be at least 1")
+	}
+	if c.add("overflow", b.Config.Overflow.AssignToDefault(&b.overflow, nil, rateLimitOverflowDrop)) {
+		switch b.overflow {
+		case rateLimitOverflowDrop, rateLimitOverflowQueue, rateLimitOverflowEmit:
+		default:
+			c.addf("overflow", "invalid overflow `%s'", b.overflow)
+		}
+	}
+	if c.add("queue_size", b.Config.QueueSize.AssignToDefault(&b.queueSize, nil, 100)) && b.queueSize < 1 {
+		c.addf("queue_size", "queue size must be at least 1")
+	}
+
+	b.buckets.configure(c, &b.Config.GroupStateConfig, b.Clock, &b.mutex, b.metrics)
+	// a group expiring sooner would lose its queue, or get a full bucket
+	// before its own had refilled
+	if refill := time.Duration(float64(b.burst) * float64(b.interval) / b.rate); b.buckets.ttl > 0 && b.rate > 0 && b.buckets.ttl <= refill {
+		c.addf("group_ttl", "group_ttl must be longer than the %s the bucket takes to refill", refill)
+	}
+	b.buckets.addGroup = b.GroupByMixin.AddGroupToSignal
+	b.buckets.onEvict = func(_ mixins.Group, value interface{}) {
+		bucket := value.(*rateLimitBucket)
+		if bucket.timer != nil {
+			bucket.timer.Stop()
+		}
+		b.metrics.dropped("evicted", len(bucket.queue))
+	}
+	b.outbox.configure(b.metrics)
+
+	return c.err()
+}
+
+func (b *RateLimitBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
+	return b.Consumer.Enqueue(terminal, signals, 1)
+}
+
+func (b *RateLimitBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	b.Splitter.EachOutput(fn)
+	b.buckets.eachOutput(fn)
+}
+
+func (b *RateLimitBlock) Start(ctx context.Context) {
+	defer b.stop()
+
+	for {
+		select {
+		case signals := <-b.ChIn:
+			b.mutex.Lock()
+			b.GroupByMixin.Process(signals, b.metrics.group(b.process))
+			b.mutex.Unlock()
+			b.outbox.flush()
+			b.Busy.Done()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// notify adds the signals processed to the outbox. It is called with the
+// mutex held, as are the releases of queued signals, so the signals of a
+// group are emitted in order.
+func (b *RateLimitBlock) notify(terminal nio.Terminal, signals nio.SignalGroup) error {
+	ch := b.ChOutLeft
+	if terminal == b.TOutRight {
+		ch = b.ChOutRight
+	}
+	b.outbox.add(terminal, ch, signals)
+	return nil
+}
+
+// process passes the signals of a group that find a token in its bucket,
+// and overflows the rest. It must be called with the mutex held.
+func (b *RateLimitBlock) process(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error {
+
+	now := clockOrReal(b.Clock).Now()
+	bucket := b.bucket(group, now)
+
+	var passed, overflowed nio.SignalGroup
+	var limited, full int
+	for _, signal := range signals {
+		// signals wait behind those queued, which only release emits
+		if len(bucket.queue) == 0 && bucket.tokens >= 1 {
+			bucket.tokens--
+			passed = append(passed, signal)
+			continue
+		}
+
+		switch b.overflow {
+		case rateLimitOverflowDrop:
+			limited++
+		case rateLimitOverflowQueue:
+			if int64(len(bucket.queue)) < b.queueSize {
+				bucket.queue = append(bucket.queue, signal)
+			} else {
+				full++
+			}
+		case rateLimitOverflowEmit:
+			overflowed = append(overflowed, signal)
+		}
+	}
+
+	b.buckets.set(group, bucket)
+	b.schedule(group, bucket)
+	b.metrics.dropped("rate_limited", limited)
+	b.metrics.dropped("queue_full", full)
+
+	if len(passed) > 0 {
+		if err := notify(b.TOutLeft, passed); err != nil {
+			return err
+		}
+	}
+	if len(overflowed) > 0 {
+		return notify(b.TOutRight, overflowed)
+	}
+	return nil
+}
+
+// bucket returns the bucket of a group refilled up to now, or a full bucket
+// for a group seen for the first time. It must be called with the mutex
+// held.
+func (b *RateLimitBlock) bucket(group mixins.Group, now time.Time) *rateLimitBucket {
+	value, ok := b.buckets.get(group)
+	if !ok {
+		return &rateLimitBucket{tokens: float64(b.burst), updated: now}
+	}
+
+	bucket := value.(*rateLimitBucket)
+	refill := now.Sub(bucket.updated).Seconds() * b.rate / b.interval.Seconds()
+	bucket.tokens = math.Min(float64(b.burst), bucket.tokens+refill)
+	bucket.updated = now
+
+	// absorb rounding, so a bucket refilled for just as long as a token
+	// takes holds the whole token
+	if whole := math.Round(bucket.tokens); math.Abs(bucket.tokens-whole) < 1e-9 {
+		bucket.tokens = whole
+	}
+	return bucket
+}
+
+// schedule arms the timer that releases the queue of a bucket once its next
+// token is refilled, unless it is armed already. It must be called with the
+// mutex held.
+func (b *RateLimitBlock) schedule(group mixins.Group, bucket *rateLimitBucket) {
+	if bucket.timer != nil || len(bucket.queue) == 0 {
+		return
+	}
+
+	wait := time.Duration(math.Ceil((1 - bucket.tokens) * float64(b.interval) / b.rate))
+	bucket.timer = clockOrReal(b.Clock).AfterFunc(wait, func() { b.release(group, bucket) })
+}
+
+// release emits as many queued signals of a group as there are tokens. They
+// are added to the outbox with the mutex held, so the signals of a group are
+// emitted in order, and sent once it is released.
+func (b *RateLimitBlock) release(group mixins.Group, bucket *rateLimitBucket) {
+	defer b.outbox.flush()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// the group was evicted or the block stopped
+	if value, ok := b.buckets.peek(group); !ok || value != bucket || bucket.timer == nil {
+		return
+	}
+	bucket.timer = nil
+
+	b.bucket(group, clockOrReal(b.Clock).Now())
+
+	n := int(math.Min(math.Floor(bucket.tokens), float64(len(bucket.queue))))
+	released := bucket.queue[:n]
+	bucket.queue = bucket.queue[n:]
+	bucket.tokens -= float64(n)
+
+	b.buckets.set(group, bucket)
+	b.schedule(group, bucket)
+
+	if n > 0 {
+		b.outbox.add(b.TOutLeft, b.ChOutLeft, released)
+	}
+}
+
+// stop disarms the release of every queue when the block stops; the queued
+// signals are dropped, and signals still in the outbox are sent only if
+// their output has room.
+func (b *RateLimitBlock) stop() {
+	b.outbox.close()
+	b.outbox.flush()
+
+	b.mutex.Lock()
+	b.buckets.each(func(_ mixins.Group, value interface{}) {
+		bucket := value.(*rateLimitBucket)
+		if bucket.timer != nil {
+			bucket.timer.Stop()
+			bucket.timer = nil
+		}
+		b.metrics.dropped("stopped", len(bucket.queue))
+		bucket.queue = nil
+	})
+	b.mutex.Unlock()
+
+	b.buckets.stop()
+}
+
+const rateLimitVersion = "0.1.0"
+
+var RateLimit = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &RateLimitBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.rate_limit.rate_limit_block.RateLimit",
+		Version:    rateLimitVersion,
+		Name:       "RateLimit",
+		Properties: groupedProperties(RateLimitBlockConfig{}, rateLimitVersion),
+		Commands:   map[nio.Command]nio.CommandDefinition{},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+				{
+					Label:   "overflow",
+					Type:    "output",
+					Visible: true,
+					Order:   1,
+					ID:      "overflow",
+					Default: false,
+				},
+				groupEvictedOutput(2),
+			},
+		},
+	},
+}
@@ -0,0 +1,244 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitBlock_Drop(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	metrics := newRecordedMetrics()
+	b := stdlib.RateLimitBlock{Clock: clock, Metrics: metrics}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "RateLimit",
+	"rate": 1,
+	"interval": {"seconds": 1},
+	"burst": 2
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"n": 1}, nio.Signal{"n": 2}, nio.Signal{"n": 3})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"n": 1},
+		nio.Signal{"n": 2},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+
+	clock.Advance(500 * time.Millisecond)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"n": 4})
+	takeNone(t, b.ChOutLeft, &b.Busy)
+
+	// a token takes a second to refill, and the bucket holds no more than
+	// the burst
+	clock.Advance(time.Second)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"n": 5}, nio.Signal{"n": 6})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"n": 5},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+
+	clock.Advance(time.Minute)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"n": 7}, nio.Signal{"n": 8}, nio.Signal{"n": 9})
+	assert.Len(takeOne(t, b.ChOutLeft, &b.Busy), 2)
+	takeNone(t, b.ChOutRight, &b.Busy)
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	assert.Equal(map[string]int{"rate_limited": 4}, metrics.signalsDropped["RateLimit"])
+}
+
+func TestRateLimitBlock_Queue(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.RateLimitBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "RateLimit",
+	"rate": 2,
+	"interval": {"seconds": 1},
+	"overflow": "queue",
+	"queue_size": 3
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"n": 1},
+		nio.Signal{"n": 2},
+		nio.Signal{"n": 3},
+		nio.Signal{"n": 4},
+		// beyond the queue size
+		nio.Signal{"n": 5},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"n": 1},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+
+	clock.Advance(499 * time.Millisecond)
+	takeNone(t, b.ChOutLeft, &b.Busy)
+
+	clock.Advance(time.Millisecond)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"n": 2},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+
+	// signals arriving while others are queued wait their turn
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"n": 6})
+	takeNone(t, b.ChOutLeft, &b.Busy)
+
+	clock.Advance(time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"n": 3},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"n": 4},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+
+	clock.Advance(500 * time.Millisecond)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"n": 6},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+
+	clock.Advance(time.Minute)
+	takeNone(t, b.ChOutLeft, &b.Busy)
+}
+
+func TestRateLimitBlock_Overflow(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.RateLimitBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "RateLimit",
+	"rate": 10,
+	"interval": {"minutes": 1},
+	"burst": 1,
+	"overflow": "overflow",
+	"group_by": "{{ $device }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"device": "a", "n": 1},
+		nio.Signal{"device": "b", "n": 2},
+		nio.Signal{"device": "a", "n": 3},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"device": "a", "n": 1},
+		nio.Signal{"device": "b", "n": 2},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"device": "a", "n": 3},
+	}, takeOne(t, b.ChOutRight, &b.Busy))
+
+	clock.Advance(6 * time.Second)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"device": "a", "n": 4})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"device": "a", "n": 4},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+}
+
+func TestRateLimitBlock_QueueDropped(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	metrics := newRecordedMetrics()
+	b := stdlib.RateLimitBlock{Clock: clock, Metrics: metrics}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "RateLimit",
+	"group_by": "{{ $group }}",
+	"max_groups": 1,
+	"overflow": "queue",
+	"queue_size": 1
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"group": "a", "n": 1},
+		nio.Signal{"group": "a", "n": 2},
+		// beyond the queue size
+		nio.Signal{"group": "a", "n": 3},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "a", "n": 1},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+
+	// the queue of the evicted group goes with it
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "b", "n": 4})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "b", "n": 4},
+	}, takeOne(t, b.ChOutLeft, &b.Busy))
+
+	clock.Advance(time.Minute)
+	takeNone(t, b.ChOutLeft, &b.Busy)
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	assert.Equal(map[string]int{
+		"queue_full": 1,
+		"evicted":    1,
+	}, metrics.signalsDropped["RateLimit"])
+}
+
+func TestRateLimitBlock_ConfigErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.RateLimitBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "RateLimit",
+	"rate": 0,
+	"burst": 0,
+	"overflow": "block"
+}`))
+
+	if assert.IsType(stdlib.ConfigErrors{}, err) {
+		var properties []string
+		for _, e := range err.(stdlib.ConfigErrors) {
+			properties = append(properties, e.Property)
+		}
+		assert.Equal([]string{"rate", "burst", "overflow"}, properties)
+	}
+
+	// a group would expire before its bucket had refilled
+	err = b.Configure(nio.RawBlockConfig(`{
+	"type": "RateLimit",
+	"rate": 1,
+	"interval": {"seconds": 10},
+	"burst": 3,
+	"overflow": "queue",
+	"group_ttl": {"seconds": 30}
+}`))
+	assert.EqualError(err, "RateLimit: group_ttl: group_ttl must be longer than the 30s the bucket takes to refill")
+}
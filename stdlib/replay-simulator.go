@@ -0,0 +1,419 @@
+package stdlib
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// ReplaySimulatorBlock replays signals recorded in a JSON-lines or CSV file.
+// Without a timestamp attribute, records are emitted num_signals at a time on
+// every interval. With one, records are emitted at the pace they were
+// recorded, optionally sped up, and records sharing a timestamp are emitted
+// together.
+//
+// Records that fail to parse are skipped and counted as dropped, with the
+// reason "malformed". An error reading the file ends the replay, and is
+// logged.
+//
+// A replay paced by recorded timestamps takes the same commands and trigger
+// input as any other simulator, and honours start_delay, jitter and
+// total_signals, but emits the records of one timestamp at a time in place of
+// num_signals, and so has no interval to set.
+type ReplaySimulatorBlock struct {
+	nio.Producer
+	Config ReplaySimulatorConfig
+
+	// Clock paces the simulation. It defaults to RealClock.
+	Clock Clock
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	sim simulatorEngine
+
+	path         string
+	format       string
+	timestampKey string
+	speed        float64
+	loop         bool
+
+	source replaySource
+
+	// the record read ahead when replaying recorded timestamps, and when
+	// it and the records last emitted were recorded
+	ahead   nio.Signal
+	aheadAt time.Time
+	groupAt time.Time
+}
+
+type ReplaySimulatorConfig struct {
+	nio.BlockConfigAtom
+	IntervalSimulatorConfig
+
+	Path          *props.StringProperty  `json:"path" title:"File" order:"0" type:"FileType"`
+	Format        *props.StringProperty  `json:"format" title:"Format" order:"1" options:"auto=,jsonl,csv" default:""`
+	TimestampAttr *props.StringProperty  `json:"timestamp_attr" title:"Timestamp Attribute" order:"2" allow_none:"true" default:""`
+	Speed         *props.AnyProperty     `json:"speed" title:"Speed Multiplier" order:"3" type:"FloatType" default:"1"`
+	Loop          *props.BooleanProperty `json:"loop" title:"Loop" order:"4" default:"false"`
+}
+
+const (
+	replayFormatJSONLines = "jsonl"
+	replayFormatCSV       = "csv"
+)
+
+func (b *ReplaySimulatorBlock) Configure(config nio.RawBlockConfig) error {
+	b.Producer.Configure()
+
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+
+	b.sim.configure(c, &b.Config.IntervalSimulatorConfig, b.Clock, b.Metrics)
+	// a reset replays the file from the start
+	b.sim.onReset = b.rewind
+	b.sim.onStop = b.closeSource
+	b.sim.wait = nil
+
+	if c.add("path", b.Config.Path.AssignToDefault(&b.path, nil, "")) {
+		if _, err := os.Stat(b.path); err != nil {
+			c.add("path", err)
+		}
+	}
+
+	if c.add("format", b.Config.Format.AssignToDefault(&b.format, nil, "")) {
+		if b.format == "" {
+			switch strings.ToLower(filepath.Ext(b.path)) {
+			case ".csv":
+				b.format = replayFormatCSV
+			default:
+				b.format = replayFormatJSONLines
+			}
+		}
+		switch b.format {
+		case replayFormatJSONLines, replayFormatCSV:
+		default:
+			c.addf("format", "invalid format `%s'", b.format)
+		}
+	}
+
+	if c.add("timestamp_attr", b.Config.TimestampAttr.AssignToDefault(&b.timestampKey, nil, "")) && b.timestampKey != "" {
+		if b.sim.count != 1 {
+			c.addf("num_signals", "num_signals does not apply when replaying recorded timestamps")
+		}
+		b.sim.count = math.MaxInt64
+		b.sim.wait = b.waitRecorded
+	}
+	b.rewind()
+
+	if c.add("speed", assignFloatDefault(b.Config.Speed, &b.speed, 1)) && b.speed <= 0 {
+		c.addf("speed", "speed must be positive")
+	}
+
+	c.add("loop", b.Config.Loop.AssignToDefault(&b.loop, nil, false))
+
+	return c.err()
+}
+
+func (b *ReplaySimulatorBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	if b.sim.enqueue(terminal, signals) {
+		return nil
+	}
+	return b.NoEnqueue(terminal)
+}
+
+// Command controls the replay. Replays paced by recorded timestamps have no
+// interval to set.
+func (b *ReplaySimulatorBlock) Command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	if b.timestampKey != "" && command == "set_interval" {
+		return nil, fmt.Errorf("set_interval: not supported when replaying recorded timestamps")
+	}
+	return b.sim.command(command, args)
+}
+
+func (b *ReplaySimulatorBlock) Start(ctx context.Context) {
+	generate := b.generate
+	if b.timestampKey != "" {
+		generate = b.generateRecorded
+	}
+	b.sim.run(ctx, b.TOut, b.ChOut, generate)
+}
+
+func (b *ReplaySimulatorBlock) generate(num int64) (nio.SignalGroup, bool) {
+	signals := make(nio.SignalGroup, 0, num)
+	for int64(len(signals)) < num {
+		signal, err := b.next()
+		if err != nil {
+			return signals, true
+		}
+		signals = append(signals, signal)
+	}
+	return signals, false
+}
+
+// generateRecorded returns the next records sharing a timestamp, up to num
+// of them, reading ahead to the first record after them to pace the next
+// batch.
+func (b *ReplaySimulatorBlock) generateRecorded(num int64) (nio.SignalGroup, bool) {
+	var group nio.SignalGroup
+	for int64(len(group)) < num {
+		if b.ahead == nil {
+			signal, err := b.next()
+			if err != nil {
+				return group, true
+			}
+
+			at, ok := replayTimestamp(signal[b.timestampKey])
+			if !ok {
+				// untimed records go out with the records before them
+				at = b.aheadAt
+			}
+			b.ahead, b.aheadAt = signal, at
+		}
+
+		if len(group) > 0 && !b.aheadAt.Equal(b.groupAt) {
+			break
+		}
+		group = append(group, b.ahead)
+		b.groupAt = b.aheadAt
+		b.ahead = nil
+	}
+	return group, false
+}
+
+// waitRecorded returns the time from the records last emitted to the next
+// ones, as recorded and sped up. The first records go out at once.
+func (b *ReplaySimulatorBlock) waitRecorded() time.Duration {
+	if b.ahead == nil {
+		return 0
+	}
+
+	wait := time.Duration(float64(b.aheadAt.Sub(b.groupAt)) / b.speed)
+	if wait < 0 {
+		// the file looped
+		return 0
+	}
+	return wait
+}
+
+// rewind replays the file from the start.
+func (b *ReplaySimulatorBlock) rewind() {
+	b.closeSource()
+	b.ahead = nil
+	b.aheadAt = time.Time{}
+	b.groupAt = time.Time{}
+}
+
+// next returns the next record, rewinding to the start of the file when
+// looping, and skipping malformed records. It returns io.EOF once the replay
+// is exhausted, or any other error that ends it, which it logs.
+func (b *ReplaySimulatorBlock) next() (nio.Signal, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		if b.source == nil {
+			source, err := openReplaySource(b.path, b.format)
+			if err != nil {
+				log.Printf("%s: %v", b.sim.metrics.block, err)
+				return nil, err
+			}
+			b.source = source
+		}
+
+		signal, err := b.source.next()
+		for isReplayMalformed(err) {
+			b.sim.metrics.dropped("malformed", 1)
+			signal, err = b.source.next()
+		}
+		if err != io.EOF {
+			if err != nil {
+				log.Printf("%s: %s: %v", b.sim.metrics.block, b.path, err)
+			}
+			return signal, err
+		}
+
+		b.closeSource()
+		if !b.loop {
+			break
+		}
+	}
+
+	return nil, io.EOF
+}
+
+func (b *ReplaySimulatorBlock) closeSource() {
+	if b.source != nil {
+		b.source.Close()
+		b.source = nil
+	}
+}
+
+// replayTimestamp reads a recorded timestamp, given either as seconds since
+// the epoch or as an RFC 3339 string.
+func replayTimestamp(value interface{}) (time.Time, bool) {
+	if t, ok := value.(time.Time); ok {
+		return t, true
+	}
+
+	if s, ok := value.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, true
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		value = f
+	}
+
+	seconds, ok := toFloat(value)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
+
+// replayMalformedError is a record that could not be parsed. Reading goes on
+// with the next record.
+type replayMalformedError struct {
+	err error
+}
+
+func (e replayMalformedError) Error() string {
+	return "malformed record: " + e.err.Error()
+}
+
+func isReplayMalformed(err error) bool {
+	_, ok := err.(replayMalformedError)
+	return ok
+}
+
+type replaySource interface {
+	io.Closer
+	next() (nio.Signal, error)
+}
+
+func openReplaySource(path, format string) (replaySource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case replayFormatCSV:
+		r := csv.NewReader(f)
+		header, err := r.Read()
+		if err != nil {
+			f.Close()
+			if err == io.EOF {
+				return nil, errors.New("replay: csv file has no header")
+			}
+			return nil, err
+		}
+		return &csvReplaySource{File: f, reader: r, header: header}, nil
+	default:
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		return &jsonLinesReplaySource{File: f, scanner: scanner}, nil
+	}
+}
+
+type jsonLinesReplaySource struct {
+	*os.File
+	scanner *bufio.Scanner
+}
+
+func (s *jsonLinesReplaySource) next() (nio.Signal, error) {
+	for s.scanner.Scan() {
+		line := strings.TrimSpace(s.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var signal nio.Signal
+		if err := json.Unmarshal([]byte(line), &signal); err != nil {
+			return nil, replayMalformedError{err}
+		}
+		if signal == nil {
+			return nil, replayMalformedError{errors.New("null record")}
+		}
+		return signal, nil
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// csvReplaySource reads records keyed by the header row. Numeric fields are
+// replayed as numbers, everything else as strings.
+type csvReplaySource struct {
+	*os.File
+	reader *csv.Reader
+	header []string
+}
+
+func (s *csvReplaySource) next() (nio.Signal, error) {
+	record, err := s.reader.Read()
+	if _, ok := err.(*csv.ParseError); ok {
+		return nil, replayMalformedError{err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signal := nio.Signal{}
+	for i, key := range s.header {
+		if i >= len(record) {
+			break
+		}
+		if f, err := strconv.ParseFloat(record[i], 64); err == nil {
+			signal[key] = f
+		} else {
+			signal[key] = record[i]
+		}
+	}
+	return signal, nil
+}
+
+const replaySimulatorVersion = "0.1.0"
+
+var ReplaySimulator = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &ReplaySimulatorBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Version: replaySimulatorVersion,
+		BlockAttributes: nio.BlockAttributes{
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Inputs: simulatorInputs,
+		},
+		Namespace:  "goblocks.simulator.blocks.ReplaySimulator",
+		Properties: definitionProperties(ReplaySimulatorConfig{}, replaySimulatorVersion),
+		Commands:   simulatorCommands,
+		Name:       "ReplaySimulator",
+	},
+}
@@ -0,0 +1,306 @@
+package stdlib
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/mixins"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// SamplerBlock passes a representative subset of the signals of each group,
+// as mode says:
+//
+//	every_nth    passes the nth signal of a group, then the 2nth, and so on
+//	probability  passes each signal with the probability given
+//	reservoir    passes reservoir_size signals of a group per interval,
+//	             sampled uniformly from the signals of the interval
+//
+// An interval of a group starts with its first signal after the last
+// interval ended, and its sample is emitted, in the order the signals
+// arrived, when it ends. Samples of intervals not yet ended are dropped when
+// the block stops. The seed makes the probability and reservoir modes
+// reproducible. In reservoir mode, a group_ttl must outlast the interval.
+type SamplerBlock struct {
+	nio.Transformer
+	mixins.GroupByMixin
+	Config SamplerBlockConfig
+
+	// Clock times the reservoir intervals. It defaults to RealClock.
+	Clock Clock
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	mode          string
+	n             int64
+	probability   float64
+	reservoirSize int64
+	interval      time.Duration
+	rand          *rand.Rand
+
+	mutex   sync.Mutex
+	groups  groupState
+	metrics blockMetrics
+	outbox  outbox
+}
+
+type SamplerBlockConfig struct {
+	nio.BlockConfigAtom
+	Mode          *props.StringProperty    `json:"mode" order:"0" options:"every_nth,probability,reservoir" default:"every_nth"`
+	N             *props.IntProperty       `json:"n" title:"Every Nth" order:"1" default:"10"`
+	Probability   *props.AnyProperty       `json:"probability" order:"2" type:"FloatType" default:"0.1"`
+	ReservoirSize *props.IntProperty       `json:"reservoir_size" order:"3" default:"10"`
+	Interval      *props.TimeDeltaProperty `json:"interval" order:"4" default:"{\"seconds\": 1}"`
+	Seed          *props.IntProperty       `json:"seed" title:"Random Seed" order:"5" advanced:"true" allow_none:"true"`
+	GroupStateConfig
+}
+
+// The modes of sampling.
+const (
+	samplerEveryNth    = "every_nth"
+	samplerProbability = "probability"
+	samplerReservoir   = "reservoir"
+)
+
+// samplerGroup is the sampling state of a group: how many signals it has
+// counted towards the nth, or the reservoir of its current interval.
+type samplerGroup struct {
+	count     int64
+	reservoir []samplerSample
+	timer     Timer
+}
+
+// samplerSample is a signal in a reservoir, with the number of signals of
+// the interval before it, to emit the sample in order.
+type samplerSample struct {
+	index  int64
+	signal nio.Signal
+}
+
+func (b *SamplerBlock) Configure(config nio.RawBlockConfig) error {
+	b.Transformer.Configure()
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
+
+	c.add("group_by", b.GroupByMixin.Configure(config, b.metrics.notify(b.Notify)))
+
+	if c.add("mode", b.Config.Mode.AssignToDefault(&b.mode, nil, samplerEveryNth)) {
+		switch b.mode {
+		case samplerEveryNth, samplerProbability, samplerReservoir:
+		default:
+			c.addf("mode", "invalid mode `%s'", b.mode)
+		}
+	}
+	if c.add("n", b.Config.N.AssignToDefault(&b.n, nil, 10)) && b.n < 1 {
+		c.addf("n", "n must be at least 1")
+	}
+	if c.add("probability", assignFloatDefault(b.Config.Probability, &b.probability, 0.1)) && (b.probability < 0 || b.probability > 1) {
+		c.addf("probability", "probability must be between 0 and 1")
+	}
+	if c.add("reservoir_size", b.Config.ReservoirSize.AssignToDefault(&b.reservoirSize, nil, 10)) && b.reservoirSize < 1 {
+		c.addf("reservoir_size", "reservoir size must be at least 1")
+	}
+	if c.add("interval", b.Config.Interval.AssignToDefault(&b.interval, nil, time.Second)) && b.interval <= 0 {
+		c.addf("interval", "interval must be positive")
+	}
+
+	// an unset seed gives a different sample on every run
+	var seed int64
+	c.add("seed", b.Config.Seed.AssignToDefault(&seed, nil, time.Now().UnixNano()))
+	b.rand = rand.New(rand.NewSource(seed))
+
+	b.groups.configure(c, &b.Config.GroupStateConfig, b.Clock, &b.mutex, b.metrics)
+	// a group expiring sooner would lose its sample before emitting it
+	if b.mode == samplerReservoir && b.groups.ttl > 0 && b.groups.ttl <= b.interval {
+		c.addf("group_ttl", "group_ttl must be longer than the %s interval", b.interval)
+	}
+	b.groups.addGroup = b.GroupByMixin.AddGroupToSignal
+	b.groups.onEvict = func(_ mixins.Group, value interface{}) {
+		if timer := value.(*samplerGroup).timer; timer != nil {
+			timer.Stop()
+		}
+	}
+	b.outbox.configure(b.metrics)
+
+	return c.err()
+}
+
+func (b *SamplerBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
+	return b.Transformer.Enqueue(terminal, signals, 1)
+}
+
+func (b *SamplerBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	b.Transformer.EachOutput(fn)
+	b.groups.eachOutput(fn)
+}
+
+func (b *SamplerBlock) Start(ctx context.Context) {
+	defer b.stop()
+
+	for {
+		select {
+		case signals := <-b.ChIn:
+			b.GroupByMixin.Process(signals, b.metrics.group(b.process))
+			b.Busy.Done()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *SamplerBlock) process(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error {
+	b.mutex.Lock()
+
+	var sampled nio.SignalGroup
+	switch b.mode {
+	case samplerEveryNth:
+		state := b.group(group)
+		for _, signal := range signals {
+			state.count++
+			if state.count == b.n {
+				state.count = 0
+				sampled = append(sampled, signal)
+			}
+		}
+		b.groups.set(group, state)
+	case samplerProbability:
+		for _, signal := range signals {
+			if b.rand.Float64() < b.probability {
+				sampled = append(sampled, signal)
+			}
+		}
+	case samplerReservoir:
+		state := b.group(group)
+		for _, signal := range signals {
+			b.offer(state, signal)
+		}
+		b.groups.set(group, state)
+		if state.timer == nil {
+			state.timer = clockOrReal(b.Clock).AfterFunc(b.interval, func() { b.flush(group, state) })
+		}
+	}
+
+	b.mutex.Unlock()
+
+	if len(sampled) == 0 {
+		return nil
+	}
+	return notify(b.TOut, sampled)
+}
+
+// group returns the sampling state of a group. It must be called with the
+// mutex held.
+func (b *SamplerBlock) group(group mixins.Group) *samplerGroup {
+	if value, ok := b.groups.get(group); ok {
+		return value.(*samplerGroup)
+	}
+	return &samplerGroup{}
+}
+
+// offer samples a signal into the reservoir of a group, keeping every signal
+// of the interval in it with the same probability. It must be called with
+// the mutex held.
+func (b *SamplerBlock) offer(state *samplerGroup, signal nio.Signal) {
+	index := state.count
+	state.count++
+
+	if int64(len(state.reservoir)) < b.reservoirSize {
+		state.reservoir = append(state.reservoir, samplerSample{index: index, signal: signal})
+		return
+	}
+	if i := b.rand.Int63n(state.count); i < b.reservoirSize {
+		state.reservoir[i] = samplerSample{index: index, signal: signal}
+	}
+}
+
+// flush emits the sample of a group when its interval ends. It is added to
+// the outbox with the mutex held, so that samples are emitted in the order of
+// their intervals, and sent once it is released.
+func (b *SamplerBlock) flush(group mixins.Group, state *samplerGroup) {
+	defer b.outbox.flush()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// the group was evicted or the block stopped
+	if value, ok := b.groups.peek(group); !ok || value != state || state.timer == nil {
+		return
+	}
+
+	reservoir := state.reservoir
+	state.timer, state.reservoir, state.count = nil, nil, 0
+
+	sort.Slice(reservoir, func(i, j int) bool {
+		return reservoir[i].index < reservoir[j].index
+	})
+	signals := make(nio.SignalGroup, len(reservoir))
+	for i, sample := range reservoir {
+		signals[i] = sample.signal
+	}
+
+	b.outbox.add(b.TOut, b.ChOut, signals)
+}
+
+// stop disarms the end of every interval when the block stops. Samples still
+// in the outbox are sent only if the output has room.
+func (b *SamplerBlock) stop() {
+	b.outbox.close()
+	b.outbox.flush()
+
+	b.mutex.Lock()
+	b.groups.each(func(_ mixins.Group, value interface{}) {
+		state := value.(*samplerGroup)
+		if state.timer != nil {
+			state.timer.Stop()
+			state.timer = nil
+		}
+	})
+	b.mutex.Unlock()
+
+	b.groups.stop()
+}
+
+const samplerVersion = "0.1.0"
+
+var Sampler = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &SamplerBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.sampler.sampler_block.Sampler",
+		Version:    samplerVersion,
+		Name:       "Sampler",
+		Properties: groupedProperties(SamplerBlockConfig{}, samplerVersion),
+		Commands:   map[nio.Command]nio.CommandDefinition{},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+				groupEvictedOutput(1),
+			},
+		},
+	},
+}
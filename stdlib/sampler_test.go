@@ -0,0 +1,221 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func numbered(from, to int) []nio.Signal {
+	var signals []nio.Signal
+	for n := from; n <= to; n++ {
+		signals = append(signals, nio.Signal{"n": n})
+	}
+	return signals
+}
+
+func TestSamplerBlock_EveryNth(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.SamplerBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Sampler",
+	"mode": "every_nth",
+	"n": 3,
+	"group_by": "{{ $axis }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	signals := numbered(1, 7)
+	for _, signal := range signals {
+		signal["axis"] = "x"
+	}
+	put(t, &b, nio.DefaultTerminal, signals...)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"axis": "x", "n": 3},
+		nio.Signal{"axis": "x", "n": 6},
+	}, takeOne(t, b.ChOut, &b.Busy))
+
+	// the count carries over, and is kept for each group
+	put(t, &b, nio.DefaultTerminal,
+		nio.Signal{"axis": "x", "n": 8},
+		nio.Signal{"axis": "y", "n": 9},
+		nio.Signal{"axis": "x", "n": 10},
+	)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"axis": "x", "n": 10},
+	}, takeOne(t, b.ChOut, &b.Busy))
+}
+
+func TestSamplerBlock_Probability(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sample := func() nio.SignalGroup {
+		b := stdlib.SamplerBlock{}
+		if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Sampler",
+	"mode": "probability",
+	"probability": 0.25,
+	"seed": 42
+}`)); err != nil {
+			t.Fatal(err)
+		}
+
+		go b.Start(ctx)
+
+		put(t, &b, nio.DefaultTerminal, numbered(1, 400)...)
+		return takeOne(t, b.ChOut, &b.Busy)
+	}
+
+	// the same seed gives the same sample
+	first := sample()
+	assert.Equal(first, sample())
+	assert.InDelta(100, len(first), 30)
+}
+
+func TestSamplerBlock_Reservoir(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.SamplerBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Sampler",
+	"mode": "reservoir",
+	"reservoir_size": 5,
+	"interval": {"seconds": 10},
+	"seed": 7
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, numbered(1, 50)...)
+	takeNone(t, b.ChOut, &b.Busy)
+	clock.Advance(5 * time.Second)
+	put(t, &b, nio.DefaultTerminal, numbered(51, 100)...)
+	takeNone(t, b.ChOut, &b.Busy)
+
+	clock.Advance(5 * time.Second)
+	sample := takeOne(t, b.ChOut, &b.Busy)
+	if assert.Len(sample, 5) {
+		for i := 1; i < len(sample); i++ {
+			assert.True(sample[i-1]["n"].(int) < sample[i]["n"].(int), "sample is out of order")
+		}
+	}
+
+	// an interval with fewer signals than the reservoir emits them all
+	put(t, &b, nio.DefaultTerminal, numbered(101, 102)...)
+	b.Busy.Wait()
+	clock.Advance(10 * time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"n": 101},
+		nio.Signal{"n": 102},
+	}, takeOne(t, b.ChOut, &b.Busy))
+
+	clock.Advance(time.Minute)
+	takeNone(t, b.ChOut, &b.Busy)
+}
+
+func TestSamplerBlock_OutputFull(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.SamplerBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Sampler",
+	"mode": "reservoir",
+	"interval": {"seconds": 10},
+	"group_by": "{{ $group }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	// nothing reads the output
+	for len(b.ChOut) < cap(b.ChOut) {
+		b.ChOut <- nio.SignalGroup{}
+	}
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "a"})
+	b.Busy.Wait()
+
+	// the sample of a waits for the output without holding up the block
+	advanced := make(chan struct{})
+	go func() {
+		clock.Advance(10 * time.Second)
+		close(advanced)
+	}()
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"group": "b"})
+	processed := make(chan struct{})
+	go func() {
+		b.Busy.Wait()
+		close(processed)
+	}()
+	select {
+	case <-processed:
+	case <-time.After(hangGuard):
+		t.Fatal("b was not processed")
+	}
+
+	for i := 0; i < cap(b.ChOut); i++ {
+		<-b.ChOut
+	}
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "a"},
+	}, takeWithin(t, b.ChOut))
+	<-advanced
+}
+
+func TestSamplerBlock_ConfigErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.SamplerBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Sampler",
+	"mode": "first",
+	"n": 0,
+	"probability": 1.5
+}`))
+
+	if assert.IsType(stdlib.ConfigErrors{}, err) {
+		var properties []string
+		for _, e := range err.(stdlib.ConfigErrors) {
+			properties = append(properties, e.Property)
+		}
+		assert.Equal([]string{"mode", "n", "probability"}, properties)
+	}
+
+	b = stdlib.SamplerBlock{}
+	err = b.Configure(nio.RawBlockConfig(`{
+	"type": "Sampler",
+	"mode": "reservoir",
+	"interval": {"seconds": 60},
+	"group_ttl": {"seconds": 30}
+}`))
+	assert.EqualError(err, "Sampler: group_ttl: group_ttl must be longer than the 1m0s interval")
+}
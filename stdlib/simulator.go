@@ -0,0 +1,479 @@
+package stdlib
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// IntervalSimulatorConfig holds the properties shared by every simulator.
+// A simulator emits num_signals generated signals per interval until
+// total_signals have been emitted, and then idles until it is reset; a total
+// of zero or less never ends.
+type IntervalSimulatorConfig struct {
+	Interval   *props.TimeDeltaProperty `json:"interval" title:"Interval" order:"0" default:"{\"seconds\": 1}"`
+	Count      *props.IntProperty       `json:"num_signals" title:"Number of Signals" order:"20" default:"1"`
+	Limit      *props.IntProperty       `json:"total_signals" title:"Total Number of Signals" order:"21" default:"-1"`
+	StartDelay *props.TimeDeltaProperty `json:"start_delay" title:"Start Delay" order:"22" advanced:"true" default:"{\"seconds\": 0}"`
+	Jitter     *props.TimeDeltaProperty `json:"jitter" title:"Jitter" order:"23" advanced:"true" default:"{\"seconds\": 0}"`
+
+	// LegacyLimit is read when total_signals is unset. Deprecated: older
+	// configurations set limit, which was never part of the definitions.
+	LegacyLimit *props.IntProperty `json:"limit" definition:"-"`
+}
+
+// simulatorGenerator returns the next num signals of a simulation, and
+// whether the simulation is exhausted.
+type simulatorGenerator func(num int64) (signals nio.SignalGroup, done bool)
+
+// simulatorTriggerTerminal is the input on which every signal group makes a
+// simulator emit one batch immediately.
+const simulatorTriggerTerminal nio.Terminal = "trigger"
+
+// simulatorEngine drives a generator on an interval. Batches are scheduled
+// one interval apart, starting one interval after the start delay, and each
+// is shifted by up to jitter either way.
+//
+// The engine is controlled through commands while it runs. A command has
+// taken effect on the schedule by the time it returns, and the generator is
+// only ever called from the goroutine running the engine. Once the
+// simulation is complete, the engine stops running until it is reset, which
+// starts the simulation over one interval later.
+//
+// A reset also restarts the schedule of a running simulation, which emits
+// its next batch one interval after the reset.
+type simulatorEngine struct {
+	duration   time.Duration
+	count      int64
+	limit      int64
+	startDelay time.Duration
+	jitter     time.Duration
+
+	// onReset, when set, rewinds the generator on a reset command.
+	onReset func()
+	// onStop, when set, releases what the generator holds whenever the
+	// engine stops running.
+	onStop func()
+	// wait, when set, gives the time from one batch to the next in place
+	// of the interval. It is never called while the generator runs.
+	wait func() time.Duration
+
+	clock   Clock
+	metrics blockMetrics
+
+	mutex      sync.Mutex
+	total      int64
+	paused     bool
+	complete   bool
+	triggers   int
+	reset      bool
+	reschedule bool
+	rand       *rand.Rand
+
+	// wake carries commands to the running engine, which closes the
+	// channel it receives once it has acted on them. done is closed when
+	// the engine stops running.
+	wake chan chan struct{}
+	done chan struct{}
+
+	// what the engine was run with, to restart it
+	ctx      context.Context
+	terminal nio.Terminal
+	out      chan<- nio.SignalGroup
+	generate simulatorGenerator
+}
+
+// configure reads the shared simulator properties, recording their errors
+// in c.
+func (s *simulatorEngine) configure(c *configCheck, config *IntervalSimulatorConfig, clock Clock, metrics Metrics) {
+	s.clock = clockOrReal(clock)
+	s.metrics = newBlockMetrics(metrics, c.atom)
+
+	if c.add("interval", config.Interval.AssignToDefault(&s.duration, nil, time.Second)) && s.duration <= 0 {
+		c.addf("interval", "interval must be positive")
+	}
+	if c.add("num_signals", config.Count.AssignToDefault(&s.count, nil, 1)) && s.count < 1 {
+		c.addf("num_signals", "num_signals must be at least 1")
+	}
+
+	limit, limitName := config.Limit, "total_signals"
+	if limit == nil {
+		limit, limitName = config.LegacyLimit, "limit"
+	}
+	c.add(limitName, limit.AssignToDefault(&s.limit, nil, -1))
+
+	c.add("start_delay", config.StartDelay.AssignToDefault(&s.startDelay, nil, 0))
+	c.add("jitter", config.Jitter.AssignToDefault(&s.jitter, nil, 0))
+
+	s.total = 0
+	s.paused = false
+	s.complete = false
+	s.triggers = 0
+	s.reset = false
+	s.reschedule = false
+	s.wake = make(chan chan struct{})
+	s.done = nil
+	s.ctx = nil
+	s.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+// next advances the schedule by one interval from at, and returns the new
+// scheduled time together with the jittered time the batch is due. Jitter
+// never accumulates, as the schedule itself is kept unshifted. It must be
+// called with the mutex held.
+func (s *simulatorEngine) next(at time.Time) (scheduled, due time.Time) {
+	wait := s.duration
+	if s.wait != nil {
+		wait = s.wait()
+	}
+	scheduled = at.Add(wait)
+	due = scheduled
+	if s.jitter > 0 {
+		due = due.Add(time.Duration(s.rand.Int63n(int64(2*s.jitter))) - s.jitter)
+	}
+	return scheduled, due
+}
+
+// rewind applies a pending reset, and reports whether there was one. It
+// must be called with the mutex held, from the goroutine running the engine
+// or while it is not running.
+func (s *simulatorEngine) rewind() bool {
+	if !s.reset {
+		return false
+	}
+
+	s.reset = false
+	s.total = 0
+	s.complete = false
+	if s.onReset != nil {
+		s.onReset()
+	}
+	return true
+}
+
+// running reports whether the engine is running. It must be called with the
+// mutex held.
+func (s *simulatorEngine) running() bool {
+	if s.done == nil {
+		return false
+	}
+
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// run runs the engine until the simulation is complete or ctx is done.
+func (s *simulatorEngine) run(ctx context.Context, terminal nio.Terminal, out chan<- nio.SignalGroup, generate simulatorGenerator) {
+	s.mutex.Lock()
+	s.ctx, s.terminal, s.out, s.generate = ctx, terminal, out, generate
+	s.rewind()
+	scheduled, due := s.next(s.clock.Now().Add(s.startDelay))
+	s.done = make(chan struct{})
+	s.mutex.Unlock()
+
+	s.loop(scheduled, due)
+}
+
+// restart runs the engine again, once a complete simulation is reset, with
+// the first batch one interval later. It must be called with the mutex held.
+func (s *simulatorEngine) restart() {
+	if s.ctx == nil || s.ctx.Err() != nil {
+		return
+	}
+
+	scheduled, due := s.next(s.clock.Now())
+	s.done = make(chan struct{})
+	go s.loop(scheduled, due)
+}
+
+func (s *simulatorEngine) loop(scheduled, due time.Time) {
+	s.mutex.Lock()
+	ctx, terminal, out, generate := s.ctx, s.terminal, s.out, s.generate
+	done := s.done
+	s.mutex.Unlock()
+
+	defer func() {
+		if s.onStop != nil {
+			s.onStop()
+		}
+
+		s.mutex.Lock()
+		close(done)
+		s.mutex.Unlock()
+	}()
+
+	t := s.clock.NewTimer(due.Sub(s.clock.Now()))
+	defer t.Stop()
+
+	// emit generates and sends one batch, and reports whether the
+	// simulation is complete, which it is once total_signals have been
+	// emitted or the generator is exhausted.
+	emit := func() bool {
+		s.mutex.Lock()
+		num := s.count
+		hasLimit := s.limit > 0
+		if hasLimit && num > s.limit-s.total {
+			num = s.limit - s.total
+		}
+		s.mutex.Unlock()
+
+		start := time.Now()
+		signals, exhausted := generate(num)
+		s.metrics.processed(start)
+
+		s.mutex.Lock()
+		s.total += int64(len(signals))
+		s.complete = exhausted || (hasLimit && s.total >= s.limit)
+		complete := s.complete
+		s.mutex.Unlock()
+
+		if len(signals) > 0 {
+			select {
+			case out <- signals:
+				s.metrics.out(terminal, signals)
+			case <-ctx.Done():
+				s.metrics.dropped("stopped", len(signals))
+			}
+		}
+
+		return complete
+	}
+
+	// batches triggered before the engine started go out at once
+	s.mutex.Lock()
+	triggers := s.triggers
+	s.triggers = 0
+	s.reschedule = false
+	s.mutex.Unlock()
+
+	for ; triggers > 0; triggers-- {
+		if emit() {
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-t.C():
+			s.mutex.Lock()
+			paused := s.paused
+			s.mutex.Unlock()
+
+			if !paused && emit() {
+				return
+			}
+
+			s.mutex.Lock()
+			scheduled, due = s.next(scheduled)
+			s.mutex.Unlock()
+			t.Reset(due.Sub(s.clock.Now()))
+		case ack := <-s.wake:
+			s.mutex.Lock()
+			triggers := s.triggers
+			s.triggers = 0
+			reschedule := s.rewind() || s.reschedule
+			s.reschedule = false
+			if reschedule {
+				scheduled, due = s.next(s.clock.Now())
+			}
+			s.mutex.Unlock()
+
+			if reschedule {
+				if !t.Stop() {
+					select {
+					case <-t.C():
+					default:
+					}
+				}
+				t.Reset(due.Sub(s.clock.Now()))
+			}
+
+			// acknowledge before emitting, as the batches may have to
+			// wait for the output to be drained
+			close(ack)
+
+			for ; triggers > 0; triggers-- {
+				if emit() {
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// notify wakes the engine to act on a command, and waits until it has. When
+// the engine is not running, the command is acted on once it starts.
+func (s *simulatorEngine) notify() {
+	s.mutex.Lock()
+	done := s.done
+	s.mutex.Unlock()
+
+	if done == nil {
+		return
+	}
+
+	ack := make(chan struct{})
+	select {
+	case s.wake <- ack:
+		<-ack
+	case <-done:
+	}
+}
+
+// trigger makes the engine emit one batch immediately, whether or not it is
+// paused, unless the simulation is complete.
+func (s *simulatorEngine) trigger() {
+	s.mutex.Lock()
+	if s.complete {
+		s.mutex.Unlock()
+		return
+	}
+	s.triggers++
+	s.mutex.Unlock()
+
+	s.notify()
+}
+
+// enqueue handles a signal group arriving on a simulator's input.
+func (s *simulatorEngine) enqueue(terminal nio.Terminal, signals nio.SignalGroup) bool {
+	if terminal != simulatorTriggerTerminal {
+		return false
+	}
+
+	s.metrics.in(terminal, signals)
+
+	s.trigger()
+	return true
+}
+
+// command runs one of simulatorCommands and returns the engine's status.
+func (s *simulatorEngine) command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	switch command {
+	case "start", "stop", "reset", "set_interval":
+		if err := s.control(command, args); err != nil {
+			return nil, err
+		}
+	case "emit":
+		s.trigger()
+	case "status":
+	default:
+		return nil, unknownCommand(command)
+	}
+
+	return s.status(), nil
+}
+
+func (s *simulatorEngine) control(command nio.Command, args map[string]interface{}) error {
+	s.mutex.Lock()
+	switch command {
+	case "start":
+		if s.paused {
+			s.paused = false
+			s.reschedule = true
+		}
+	case "stop":
+		s.paused = true
+	case "reset":
+		// a reset is applied by the engine, so it never rewinds the
+		// generator while it runs, or right away when it is not running
+		s.reset = true
+		if !s.running() {
+			complete := s.complete
+			s.rewind()
+			if complete {
+				s.restart()
+			}
+		}
+	case "set_interval":
+		seconds, ok := toFloat(args["interval"])
+		if !ok || seconds <= 0 {
+			s.mutex.Unlock()
+			return fmt.Errorf("set_interval: interval must be a positive number of seconds")
+		}
+		s.duration = time.Duration(seconds * float64(time.Second))
+		s.reschedule = true
+	}
+	s.mutex.Unlock()
+
+	s.notify()
+	return nil
+}
+
+// interval returns the current interval between batches.
+func (s *simulatorEngine) interval() time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.duration
+}
+
+func (s *simulatorEngine) status() map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return map[string]interface{}{
+		"running":  !s.paused && !s.complete,
+		"complete": s.complete,
+		"interval": s.duration.Seconds(),
+		"total":    s.total,
+	}
+}
+
+// simulatorCommands are the definitions of the commands every simulator
+// accepts.
+var simulatorCommands = map[nio.Command]nio.CommandDefinition{
+	"start": {
+		"title":  "Start",
+		"params": map[string]interface{}{},
+	},
+	"stop": {
+		"title":  "Stop",
+		"params": map[string]interface{}{},
+	},
+	"reset": {
+		"title":  "Reset",
+		"params": map[string]interface{}{},
+	},
+	"emit": {
+		"title":  "Emit Now",
+		"params": map[string]interface{}{},
+	},
+	"set_interval": {
+		"title": "Set Interval",
+		"params": map[string]interface{}{
+			"interval": map[string]interface{}{
+				"title":      "Interval (seconds)",
+				"type":       "FloatType",
+				"default":    nil,
+				"allow_none": false,
+			},
+		},
+	},
+	"status": {
+		"title":  "Status",
+		"params": map[string]interface{}{},
+	},
+}
+
+// simulatorInputs are the input terminal definitions of every simulator.
+var simulatorInputs = []nio.TerminalDefinition{
+	{
+		Label:   "trigger",
+		Type:    "input",
+		Visible: true,
+		Order:   0,
+		ID:      string(simulatorTriggerTerminal),
+		Default: false,
+	},
+}
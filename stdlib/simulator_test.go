@@ -0,0 +1,648 @@
+package stdlib_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomIntervalSimulatorBlock_Uniform(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	config := nio.RawBlockConfig(`{
+	"type": "RandomIntervalSimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 1},
+	"num_signals": 50,
+	"total_signals": 50,
+	"attr_name": "value",
+	"min": 10,
+	"max": 20,
+	"seed": 42
+}`)
+
+	var runs [2]nio.SignalGroup
+	for i := range runs {
+		b := stdlib.RandomIntervalSimulatorBlock{}
+		if err := b.Configure(config); err != nil {
+			t.Fatal(err)
+		}
+
+		go b.Start(ctx)
+		runs[i] = takeWithin(t, b.ChOut)
+	}
+
+	assert.Len(runs[0], 50)
+	for _, s := range runs[0] {
+		value := s["value"].(float64)
+		assert.True(value >= 10 && value < 20, "%f out of range", value)
+	}
+
+	assert.EqualValues(runs[0], runs[1], "a seeded simulator should be reproducible")
+}
+
+func TestRandomIntervalSimulatorBlock_Gaussian(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := stdlib.RandomIntervalSimulatorBlock{}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "RandomIntervalSimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 1},
+	"num_signals": 1000,
+	"distribution": "gaussian",
+	"mean": 100,
+	"stddev": 0.5,
+	"seed": 1
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	signals := takeWithin(t, b.ChOut)
+	assert.Len(signals, 1000)
+
+	sum := 0.0
+	for _, s := range signals {
+		sum += s["sim"].(float64)
+	}
+	assert.InDelta(100, sum/1000, 0.1)
+}
+
+func TestRandomIntervalSimulatorBlock_InvalidDistribution(t *testing.T) {
+	b := stdlib.RandomIntervalSimulatorBlock{}
+	assert.Error(t, b.Configure(nio.RawBlockConfig(`{
+	"type": "RandomIntervalSimulator",
+	"interval": {"seconds": 1},
+	"distribution": "poisson"
+}`)))
+}
+
+func TestRandomIntervalSimulatorBlock_ConfigErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.RandomIntervalSimulatorBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "RandomIntervalSimulator",
+	"interval": {"seconds": 1},
+	"min": 10,
+	"max": 5,
+	"stddev": -1
+}`))
+
+	if assert.IsType(stdlib.ConfigErrors{}, err) {
+		var properties []string
+		for _, e := range err.(stdlib.ConfigErrors) {
+			properties = append(properties, e.Property)
+		}
+		assert.Equal([]string{"max", "stddev"}, properties)
+	}
+	assert.Contains(err.Error(), "RandomIntervalSimulator: max: max must not be less than min")
+	assert.Contains(err.Error(), "RandomIntervalSimulator: stddev: stddev must not be negative")
+}
+
+func TestWaveformIntervalSimulatorBlock_InvalidConfig(t *testing.T) {
+	b := stdlib.WaveformIntervalSimulatorBlock{}
+	assert.EqualError(t, b.Configure(nio.RawBlockConfig(`{
+	"type": "WaveformIntervalSimulator",
+	"interval": {"seconds": 0},
+	"num_signals": 0
+}`)), "WaveformIntervalSimulator: interval: interval must be positive; WaveformIntervalSimulator: num_signals: num_signals must be at least 1")
+}
+
+func TestWaveformIntervalSimulatorBlock(t *testing.T) {
+	for shape, expected := range map[string][]float64{
+		"sine":     {0, 2, 0, -2, 0},
+		"square":   {2, 2, -2, -2, 2},
+		"sawtooth": {-2, -1, 0, 1, -2},
+	} {
+		t.Run(shape, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer func() {
+				cancel()
+				<-ctx.Done()
+			}()
+
+			b := stdlib.WaveformIntervalSimulatorBlock{}
+
+			// four samples per period
+			if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "WaveformIntervalSimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 10},
+	"period": {"milliseconds": 10},
+	"num_signals": 4,
+	"amplitude": 2,
+	"shape": "` + shape + `"
+}`)); err != nil {
+				t.Fatal(err)
+			}
+
+			go b.Start(ctx)
+
+			signals := append(takeWithin(t, b.ChOut), takeWithin(t, b.ChOut)...)
+			assert.Len(signals, 8)
+			for i, value := range expected {
+				assert.InDelta(value, signals[i]["sim"], 1e-9, "sample %d", i)
+			}
+		})
+	}
+}
+
+func TestWaveformIntervalSimulatorBlock_Phase(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := stdlib.WaveformIntervalSimulatorBlock{}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "WaveformIntervalSimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 10},
+	"period": {"seconds": 1},
+	"phase": 90,
+	"offset": 5
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	signals := takeWithin(t, b.ChOut)
+	assert.InDelta(5+math.Sin(math.Pi/2), signals[0]["sim"], 1e-9)
+}
+
+func TestReplaySimulatorBlock_Interval(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := stdlib.ReplaySimulatorBlock{}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "ReplaySimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 1},
+	"num_signals": 3,
+	"path": "testdata/replay.jsonl"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.Start(ctx)
+		close(done)
+	}()
+
+	assert.EqualValues(nio.SignalGroup{
+		{"t": 0.0, "value": 1.0},
+		{"t": 0.0, "value": 2.0},
+		{"t": 1.0, "value": 3.0},
+	}, takeWithin(t, b.ChOut))
+	assert.EqualValues(nio.SignalGroup{
+		{"t": 3.0, "value": 4.0},
+	}, takeWithin(t, b.ChOut))
+
+	// the replay ends at the end of the file
+	waitReturn(t, done)
+	expectNone(t, b.ChOut)
+}
+
+func TestReplaySimulatorBlock_Loop(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := stdlib.ReplaySimulatorBlock{}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "ReplaySimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 1},
+	"num_signals": 6,
+	"total_signals": 6,
+	"loop": true,
+	"path": "testdata/replay.jsonl"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	signals := takeWithin(t, b.ChOut)
+	assert.Len(signals, 6)
+	assert.EqualValues(signals[0], signals[4])
+}
+
+func TestReplaySimulatorBlock_Recorded(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.ReplaySimulatorBlock{Clock: clock}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "ReplaySimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"path": "testdata/replay.csv",
+	"timestamp_attr": "t",
+	"speed": 100
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	// the first records go out at once
+	settle(t, clock, 1)
+	clock.Advance(0)
+	assert.EqualValues(nio.SignalGroup{
+		{"t": "2018-06-01T12:00:00Z", "value": 20.5, "unit": "C"},
+	}, takeWithin(t, b.ChOut))
+
+	// one recorded second at a hundred times the speed
+	settle(t, clock, 1)
+	clock.Advance(10*time.Millisecond - time.Nanosecond)
+	settle(t, clock, 1)
+	expectNone(t, b.ChOut)
+	clock.Advance(time.Nanosecond)
+
+	assert.EqualValues(nio.SignalGroup{
+		{"t": "2018-06-01T12:00:01Z", "value": 21.0, "unit": "C"},
+		{"t": "2018-06-01T12:00:01Z", "value": 21.5, "unit": "C"},
+	}, takeWithin(t, b.ChOut))
+}
+
+func TestReplaySimulatorBlock_RecordedCommands(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.ReplaySimulatorBlock{Clock: clock}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "ReplaySimulator",
+	"path": "testdata/replay.csv",
+	"timestamp_attr": "t",
+	"start_delay": {"seconds": 5}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	settle(t, clock, 1)
+	clock.Advance(5*time.Second - time.Nanosecond)
+	settle(t, clock, 1)
+	expectNone(t, b.ChOut)
+	clock.Advance(time.Nanosecond)
+	assert.Len(takeWithin(t, b.ChOut), 1)
+
+	settle(t, clock, 1)
+	status, err := b.Command("stop", nil)
+	assert.NoError(err)
+	assert.Equal(false, status.(map[string]interface{})["running"])
+	clock.Advance(time.Minute)
+	settle(t, clock, 1)
+	expectNone(t, b.ChOut)
+
+	// emit works while stopped, and emitting past the end completes the
+	// replay
+	_, err = b.Command("emit", nil)
+	assert.NoError(err)
+	assert.Len(takeWithin(t, b.ChOut), 2)
+	status, err = b.Command("emit", nil)
+	assert.NoError(err)
+	assert.Equal(true, status.(map[string]interface{})["complete"])
+	assert.Equal(int64(3), status.(map[string]interface{})["total"])
+
+	// a reset replays the file from the start
+	status, err = b.Command("reset", nil)
+	assert.NoError(err)
+	assert.Equal(int64(0), status.(map[string]interface{})["total"])
+	_, err = b.Command("start", nil)
+	assert.NoError(err)
+	settle(t, clock, 1)
+	clock.Advance(0)
+	assert.EqualValues(nio.SignalGroup{
+		{"t": "2018-06-01T12:00:00Z", "value": 20.5, "unit": "C"},
+	}, takeWithin(t, b.ChOut))
+
+	_, err = b.Command("set_interval", map[string]interface{}{"interval": 1})
+	assert.Error(err)
+}
+
+func TestReplaySimulatorBlock_RecordedConfigErrors(t *testing.T) {
+	b := stdlib.ReplaySimulatorBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "ReplaySimulator",
+	"path": "testdata/replay.csv",
+	"timestamp_attr": "t",
+	"num_signals": 2
+}`))
+	assert.EqualError(t, err, "ReplaySimulator: num_signals: num_signals does not apply when replaying recorded timestamps")
+}
+
+func TestReplaySimulatorBlock_MissingFile(t *testing.T) {
+	b := stdlib.ReplaySimulatorBlock{}
+	assert.Error(t, b.Configure(nio.RawBlockConfig(`{
+	"type": "ReplaySimulator",
+	"interval": {"seconds": 1},
+	"path": "testdata/missing.jsonl"
+}`)))
+}
+
+func TestReplaySimulatorBlock_Malformed(t *testing.T) {
+	for path, malformed := range map[string]int{
+		"testdata/replay-malformed.jsonl": 3,
+		"testdata/replay-malformed.csv":   1,
+	} {
+		path, malformed := path, malformed
+		t.Run(path, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			metrics := newRecordedMetrics()
+			b := stdlib.ReplaySimulatorBlock{Metrics: metrics}
+			if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "ReplaySimulator",
+	"interval": {"milliseconds": 1},
+	"num_signals": 2,
+	"path": "` + path + `"
+}`)); err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				b.Start(ctx)
+				close(done)
+			}()
+
+			// the malformed records are skipped, not the end of the replay
+			signals := takeWithin(t, b.ChOut)
+			assert.Len(signals, 2)
+			waitReturn(t, done)
+			expectNone(t, b.ChOut)
+
+			metrics.mutex.Lock()
+			defer metrics.mutex.Unlock()
+			assert.Equal(malformed, metrics.signalsDropped["ReplaySimulator"]["malformed"])
+		})
+	}
+}
+
+func TestCounterIntervalSimulatorBlock_TotalSignals(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.CounterIntervalSimulatorBlock{}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "CounterIntervalSimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 5},
+	"num_signals": 2,
+	"total_signals": 5,
+	"attr_name": "count",
+	"attr_value": {"start": 0, "end": 2, "step": 1}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.Start(ctx)
+		close(done)
+	}()
+
+	var values []interface{}
+	for _, size := range []int{2, 2, 1} {
+		signals := takeWithin(t, b.ChOut)
+		assert.Len(signals, size)
+		for _, s := range signals {
+			values = append(values, s["count"])
+		}
+	}
+	assert.Equal([]interface{}{int64(0), int64(1), int64(2), int64(0), int64(1)}, values)
+
+	// the simulator stops after total_signals
+	waitReturn(t, done)
+
+	status, err := b.Command("status", nil)
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{
+		"running":  false,
+		"complete": true,
+		"interval": 0.005,
+		"total":    int64(5),
+	}, status)
+
+	// a complete simulation emits nothing more
+	_, err = b.Command("emit", nil)
+	assert.NoError(err)
+	expectNone(t, b.ChOut)
+
+	// until it is reset, which takes effect at once and starts it over
+	status, err = b.Command("reset", nil)
+	assert.NoError(err)
+	assert.Equal(int64(0), status.(map[string]interface{})["total"])
+	assert.Equal(false, status.(map[string]interface{})["complete"])
+	assert.Equal(true, status.(map[string]interface{})["running"])
+	assert.Equal(int64(0), takeWithin(t, b.ChOut)[0]["count"])
+}
+
+func TestIdentityIntervalSimulatorBlock_LegacyLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := stdlib.IdentityIntervalSimulatorBlock{}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "IdentityIntervalSimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 5},
+	"num_signals": 3,
+	"limit": 3
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.Start(ctx)
+		close(done)
+	}()
+
+	assert.Len(takeWithin(t, b.ChOut), 3)
+
+	// the simulator stops once the limit is reached
+	waitReturn(t, done)
+}
+
+func TestIdentityIntervalSimulatorBlock_StartDelay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.IdentityIntervalSimulatorBlock{Clock: clock}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "IdentityIntervalSimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 20},
+	"start_delay": {"milliseconds": 50}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	// the first batch is due after the delay and one interval
+	settle(t, clock, 1)
+	clock.Advance(69 * time.Millisecond)
+	settle(t, clock, 1)
+	expectNone(t, b.ChOut)
+	clock.Advance(time.Millisecond)
+	takeWithin(t, b.ChOut)
+
+	// later batches are not delayed
+	settle(t, clock, 1)
+	clock.Advance(20 * time.Millisecond)
+	takeWithin(t, b.ChOut)
+}
+
+func TestIdentityIntervalSimulatorBlock_Jitter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.IdentityIntervalSimulatorBlock{Clock: clock}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "IdentityIntervalSimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 30},
+	"jitter": {"milliseconds": 10},
+	"total_signals": 5
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	start := clock.Now()
+	go b.Start(ctx)
+
+	// batches are due on a fixed schedule, each shifted by the jitter
+	// without the shifts accumulating
+	for i := 1; i <= 5; i++ {
+		scheduled := start.Add(time.Duration(i) * 30 * time.Millisecond)
+
+		settle(t, clock, 1)
+		clock.Advance(scheduled.Add(-10*time.Millisecond - time.Nanosecond).Sub(clock.Now()))
+		settle(t, clock, 1)
+		expectNone(t, b.ChOut)
+
+		clock.Advance(20 * time.Millisecond)
+		takeWithin(t, b.ChOut)
+	}
+}
+
+func TestCounterIntervalSimulatorBlock_Commands(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.CounterIntervalSimulatorBlock{Clock: clock}
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "CounterIntervalSimulator",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"interval": {"milliseconds": 20},
+	"attr_name": "count",
+	"attr_value": {"start": 0, "end": 100, "step": 1}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	settle(t, clock, 1)
+	clock.Advance(20 * time.Millisecond)
+	assert.Equal(int64(0), takeWithin(t, b.ChOut)[0]["count"])
+
+	status, err := b.Command("stop", nil)
+	assert.NoError(err)
+	assert.Equal(false, status.(map[string]interface{})["running"])
+
+	settle(t, clock, 1)
+	clock.Advance(100 * time.Millisecond)
+	settle(t, clock, 1)
+	expectNone(t, b.ChOut)
+
+	// emit works while stopped
+	_, err = b.Command("emit", nil)
+	assert.NoError(err)
+	assert.Equal(int64(1), takeWithin(t, b.ChOut)[0]["count"])
+
+	// so does the trigger input
+	assert.NoError(b.Enqueue("trigger", nio.SignalGroup{{}}))
+	assert.Equal(int64(2), takeWithin(t, b.ChOut)[0]["count"])
+
+	status, err = b.Command("reset", nil)
+	assert.NoError(err)
+	assert.Equal(int64(0), status.(map[string]interface{})["total"])
+	_, err = b.Command("set_interval", map[string]interface{}{"interval": 0.01})
+	assert.NoError(err)
+	status, err = b.Command("start", nil)
+	assert.NoError(err)
+	assert.Equal(true, status.(map[string]interface{})["running"])
+	assert.Equal(0.01, status.(map[string]interface{})["interval"])
+
+	clock.Advance(10 * time.Millisecond)
+	assert.Equal(int64(0), takeWithin(t, b.ChOut)[0]["count"])
+
+	_, err = b.Command("set_interval", map[string]interface{}{"interval": -1})
+	assert.Error(err)
+	_, err = b.Command("bogus", nil)
+	assert.Error(err)
+	assert.Error(b.Enqueue(nio.DefaultTerminal, nio.SignalGroup{{}}))
+}
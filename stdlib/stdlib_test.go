@@ -21,7 +21,8 @@ func TestIdentityIntervalSimulatorBlock(t *testing.T) {
 		<-ctx.Done()
 	}()
 
-	b := IdentityIntervalSimulatorBlock{}
+	clock := NewFakeClock(time.Now())
+	b := IdentityIntervalSimulatorBlock{Clock: clock}
 	b.Configure(nio.RawBlockConfig(`{
 	"type": "IdentityIntervalSimulatorBlock",
 	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
@@ -33,30 +34,17 @@ func TestIdentityIntervalSimulatorBlock(t *testing.T) {
 
 	go b.Start(ctx)
 
-	{
-		start := time.Now()
-		signals := <-b.ChOut
-
-		assert.Len(signals, 1, "should emit one signal")
-		assert.Empty(signals[0], "")
-		assert.WithinDuration(
-			start.Add(time.Millisecond*50),
-			time.Now(),
-			6*time.Millisecond,
-		)
-	}
+	for i := 0; i < 2; i++ {
+		settle(t, clock, 1)
+		clock.Advance(49 * time.Millisecond)
+		settle(t, clock, 1)
+		expectNone(t, b.ChOut)
+		clock.Advance(time.Millisecond)
 
-	{
-		start := time.Now()
-		signals := <-b.ChOut
+		signals := takeWithin(t, b.ChOut)
 
 		assert.Len(signals, 1, "should emit one signal")
 		assert.Empty(signals[0], "")
-		assert.WithinDuration(
-			start.Add(time.Millisecond*50),
-			time.Now(),
-			6*time.Millisecond,
-		)
 	}
 }
 
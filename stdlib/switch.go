@@ -2,8 +2,9 @@ package stdlib
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/niolabs/gonio-framework"
 	"github.com/niolabs/gonio-framework/mixins"
@@ -15,15 +16,41 @@ type SwitchBlock struct {
 	Config SwitchBlockConfig
 	mixins.GroupByMixin
 
+	// TOutState receives a signal carrying the new state and group whenever
+	// the state of a group changes.
+	TOutState  nio.Terminal
+	ChOutState chan nio.SignalGroup
+
+	// Clock times auto resets and expires groups. It defaults to RealClock.
+	Clock Clock
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
 	initialState bool
-	switchState  map[mixins.Group]bool
-	mutex        sync.RWMutex
+	stateExpr    hoistedBool
+	toggle       bool
+	autoReset    time.Duration
+	groups       groupState
+	resets       map[mixins.Group]*switchReset
+	mutex        sync.Mutex
+	metrics      blockMetrics
+	outbox       outbox
 }
 
 type SwitchBlockConfig struct {
 	nio.BlockConfigAtom
-	StateExpr    *props.BooleanProperty `json:"state_expr"`
-	InitialState *props.BooleanProperty `json:"initial_state"`
+	StateExpr      *props.BooleanProperty   `json:"state_expr" title:"State" order:"0" allow_none:"true"`
+	InitialState   *props.BooleanProperty   `json:"initial_state" order:"1" default:"false"`
+	Toggle         *props.BooleanProperty   `json:"toggle" order:"2" default:"false"`
+	AutoResetAfter *props.TimeDeltaProperty `json:"auto_reset_after" order:"3" advanced:"true" default:"{\"seconds\": 0}"`
+	GroupStateConfig
+}
+
+// switchReset is a pending revert of a group to the initial state.
+type switchReset struct {
+	timer Timer
 }
 
 func (b *SwitchBlock) Configure(config nio.RawBlockConfig) error {
@@ -31,42 +58,64 @@ func (b *SwitchBlock) Configure(config nio.RawBlockConfig) error {
 	SetTerminal(&b.TInRight, "setter")
 	SetTerminal(&b.TOutLeft, "true")
 	SetTerminal(&b.TOutRight, "false")
+	SetTerminal(&b.TOutState, "state")
 
 	b.DualTransformer.Configure()
+	b.ChOutState = newOutputChannel()
 
-	if err := json.Unmarshal(config, &b.Config); err != nil {
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
 		return err
 	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
 
-	if err := b.GroupByMixin.Configure(config, b.Notify); err != nil {
-		return err
-	}
+	c.add("group_by", b.GroupByMixin.Configure(config, b.metrics.notify(b.Notify)))
+	c.add("toggle", b.Config.Toggle.AssignToDefault(&b.toggle, nil, false))
+	c.add("initial_state", b.Config.InitialState.AssignToDefault(&b.initialState, nil, false))
+	c.add("auto_reset_after", b.Config.AutoResetAfter.AssignToDefault(&b.autoReset, nil, 0))
 
-	if b.Config.StateExpr == nil {
-		panic("state expression is unset")
+	if b.Config.StateExpr == nil && !b.toggle {
+		c.addf("state_expr", "state expression is unset")
 	}
 
-	if err := b.Config.InitialState.AssignToDefault(&b.initialState, nil, false); err != nil {
-		return err
-	}
+	b.stateExpr, err = hoistBool(b.Config.StateExpr, c.raw["state_expr"], false)
+	c.add("state_expr", err)
 
-	b.switchState = map[mixins.Group]bool{}
+	b.groups.configure(c, &b.Config.GroupStateConfig, b.Clock, &b.mutex, b.metrics)
+	b.groups.addGroup = b.GroupByMixin.AddGroupToSignal
+	// eviction forgets the state of a group silently: nothing is sent on the
+	// state output, and the group reads as the initial state again
+	b.groups.onEvict = func(group mixins.Group, _ interface{}) {
+		b.cancelReset(group)
+	}
+	b.resets = map[mixins.Group]*switchReset{}
+	b.outbox.configure(b.metrics)
 
-	return nil
+	return c.err()
 }
 
 func (b *SwitchBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
 	return b.DualConsumer.Enqueue(terminal, signals, 1)
 }
 
+func (b *SwitchBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	b.DualTransformer.EachOutput(fn)
+	fn(b.TOutState, b.ChOutState)
+	b.groups.eachOutput(fn)
+}
+
 func (b *SwitchBlock) Start(ctx context.Context) {
+	defer b.stopResets()
+	defer b.groups.stop()
+
 	for {
 		select {
 		case signals := <-b.ChInLeft:
-			b.GroupByMixin.Process(signals, b.processGetter)
+			b.GroupByMixin.Process(signals, b.metrics.group(b.processGetter))
 			b.Busy.Done()
 		case signals := <-b.ChInRight:
-			b.GroupByMixin.Process(signals, b.processSetter)
+			b.GroupByMixin.Process(signals, b.metrics.group(b.processSetter))
 			b.Busy.Done()
 		case <-ctx.Done():
 			return
@@ -75,13 +124,12 @@ func (b *SwitchBlock) Start(ctx context.Context) {
 }
 
 func (b *SwitchBlock) processGetter(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error {
-	b.mutex.RLock()
-	defer b.mutex.RUnlock()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
 
-	state, ok := b.switchState[group]
-	if !ok {
-		state = b.initialState
-	}
+	// a group seen by a getter is known, even in the initial state
+	state := b.state(group)
+	b.groups.set(group, state)
 
 	var tOut nio.Terminal
 	if state {
@@ -94,15 +142,250 @@ func (b *SwitchBlock) processGetter(group mixins.Group, notify nio.NotifyFunc, s
 }
 
 func (b *SwitchBlock) processSetter(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error {
+	defer b.outbox.flush()
 	b.mutex.Lock()
-	defer b.mutex.Unlock()
 
-	var err error
+	prev := b.state(group)
 
-	last := signals[len(signals)-1]
-	if b.switchState[group], err = b.Config.StateExpr.Invoke(last); err != nil {
-		return err
+	next := prev
+	if b.toggle {
+		// every setter signal flips the state once
+		next = prev != (len(signals)%2 == 1)
+	} else {
+		var err error
+		last := signals[len(signals)-1]
+		if next, err = b.stateExpr.Invoke(last); err != nil {
+			b.mutex.Unlock()
+			b.metrics.expressionError("state_expr")
+			return err
+		}
 	}
 
+	b.setState(group, next)
+	if next != prev {
+		b.notifyState(group, next)
+	}
+	b.mutex.Unlock()
+
 	return nil
 }
+
+// setState sets the state of a group and schedules its reset. It must be
+// called with the mutex held.
+func (b *SwitchBlock) setState(group mixins.Group, state bool) {
+	b.groups.set(group, state)
+	b.scheduleReset(group, state)
+}
+
+// state returns the state of a group, which is the initial state until it is
+// set. It must be called with the mutex held.
+func (b *SwitchBlock) state(group mixins.Group) bool {
+	if state, ok := b.groups.get(group); ok {
+		return state.(bool)
+	}
+	return b.initialState
+}
+
+// scheduleReset arms, or disarms, the timer that reverts a group to the
+// initial state. It must be called with the mutex held.
+func (b *SwitchBlock) scheduleReset(group mixins.Group, state bool) {
+	if b.autoReset <= 0 {
+		return
+	}
+
+	b.cancelReset(group)
+
+	if state == b.initialState {
+		return
+	}
+
+	pending := &switchReset{}
+	pending.timer = clockOrReal(b.Clock).AfterFunc(b.autoReset, func() { b.reset(group, pending) })
+	b.resets[group] = pending
+}
+
+func (b *SwitchBlock) reset(group mixins.Group, pending *switchReset) {
+	defer b.outbox.flush()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// the reset was superseded by a later state change
+	if b.resets[group] != pending {
+		return
+	}
+
+	delete(b.resets, group)
+	b.groups.set(group, b.initialState)
+	b.notifyState(group, b.initialState)
+}
+
+// cancelReset disarms the pending reset of a group, if any. It must be called
+// with the mutex held.
+func (b *SwitchBlock) cancelReset(group mixins.Group) {
+	if pending, ok := b.resets[group]; ok {
+		pending.timer.Stop()
+		delete(b.resets, group)
+	}
+}
+
+// stopResets disarms every reset when the block stops. State signals still
+// in the outbox are sent only if the state output has room.
+func (b *SwitchBlock) stopResets() {
+	b.outbox.close()
+	b.outbox.flush()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for group, pending := range b.resets {
+		pending.timer.Stop()
+		delete(b.resets, group)
+	}
+}
+
+// notifyState adds a signal for the new state of a group to the outbox, to
+// be sent once the mutex is released. It must be called with the mutex held,
+// so that the changes of a group are sent in order.
+func (b *SwitchBlock) notifyState(group mixins.Group, state bool) {
+	signal := nio.Signal{"state": state}
+	b.GroupByMixin.AddGroupToSignal(group, signal, false)
+	b.outbox.add(b.TOutState, b.ChOutState, nio.SignalGroup{signal})
+}
+
+// Command inspects or sets the state of groups. get_state returns the state
+// of the group given, or of every group seen by a getter or setter;
+// set_state sets the state of a group as a setter signal would, notifying
+// the change and scheduling its reset. A group evicted from the state is
+// back in the initial state without a change being notified, so it is only
+// reported on the evicted output, when emit_evicted is set.
+func (b *SwitchBlock) Command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	switch command {
+	case "get_state":
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		if group, ok := groupArg(args); ok {
+			return switchGroupState(group, b.peekState(group)), nil
+		}
+
+		states := map[string]bool{}
+		b.groups.each(func(group mixins.Group, state interface{}) {
+			states[string(group)] = state.(bool)
+		})
+		return states, nil
+	case "set_state":
+		group, ok := groupArg(args)
+		if !ok {
+			return nil, fmt.Errorf("set_state: group is required")
+		}
+		next, ok := boolArg(args["state"])
+		if !ok {
+			return nil, fmt.Errorf("set_state: state must be a boolean")
+		}
+
+		defer b.outbox.flush()
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		prev := b.state(group)
+		b.setState(group, next)
+		if next != prev {
+			b.notifyState(group, next)
+		}
+		return switchGroupState(group, next), nil
+	default:
+		return nil, unknownCommand(command)
+	}
+}
+
+// peekState returns the state of a group like state, without touching the
+// group. It must be called with the mutex held.
+func (b *SwitchBlock) peekState(group mixins.Group) bool {
+	if state, ok := b.groups.peek(group); ok {
+		return state.(bool)
+	}
+	return b.initialState
+}
+
+func switchGroupState(group mixins.Group, state bool) map[string]interface{} {
+	return map[string]interface{}{"group": string(group), "state": state}
+}
+
+const switchVersion = "0.1.0"
+
+var Switch = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &SwitchBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.switch.switch_block.Switch",
+		Version:    switchVersion,
+		Name:       "Switch",
+		Properties: groupedProperties(SwitchBlockConfig{}, switchVersion),
+		Commands: map[nio.Command]nio.CommandDefinition{
+			"get_state": {
+				"title": "Get State",
+				"params": map[string]interface{}{
+					"group": groupParam(true),
+				},
+			},
+			"set_state": {
+				"title": "Set State",
+				"params": map[string]interface{}{
+					"group": groupParam(false),
+					"state": map[string]interface{}{
+						"title":      "State",
+						"type":       "BoolType",
+						"default":    nil,
+						"allow_none": false,
+					},
+				},
+			},
+		},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "getter",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "getter",
+					Default: true,
+				},
+				{
+					Label:   "setter",
+					Type:    "input",
+					Visible: true,
+					Order:   1,
+					ID:      "setter",
+					Default: false,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "true",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "true",
+					Default: true,
+				},
+				{
+					Label:   "false",
+					Type:    "output",
+					Visible: true,
+					Order:   1,
+					ID:      "false",
+					Default: false,
+				},
+				{
+					Label:   "state",
+					Type:    "output",
+					Visible: true,
+					Order:   2,
+					ID:      "state",
+					Default: false,
+				},
+				groupEvictedOutput(3),
+			},
+		},
+	},
+}
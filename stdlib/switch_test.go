@@ -3,6 +3,7 @@ package stdlib_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/niolabs/gonio-framework"
 	. "github.com/niolabs/gonio-blocks/stdlib"
@@ -113,3 +114,252 @@ func TestSwitchBlock_Group(t *testing.T) {
 	assert.Len(takeOne(t, b.ChOutLeft, &b.Busy), 2)
 	assert.Nil(takeNone(t, b.ChOutRight, &b.Busy), 0)
 }
+
+func TestSwitchBlock_Toggle(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := SwitchBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Switch",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"toggle": true
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, "setter", nil)
+	b.Busy.Wait()
+	put(t, &b, "getter", nil)
+	assert.Len(takeOne(t, b.ChOutLeft, &b.Busy), 1)
+	assert.EqualValues(nio.SignalGroup{{"state": true}}, takeOne(t, b.ChOutState, &b.Busy))
+
+	// an even number of flips leaves the state unchanged
+	put(t, &b, "setter", nil, nil)
+	b.Busy.Wait()
+	put(t, &b, "getter", nil)
+	assert.Len(takeOne(t, b.ChOutLeft, &b.Busy), 1)
+	assert.Nil(takeNone(t, b.ChOutState, &b.Busy))
+
+	put(t, &b, "setter", nil)
+	b.Busy.Wait()
+	put(t, &b, "getter", nil)
+	assert.Len(takeOne(t, b.ChOutRight, &b.Busy), 1)
+	assert.EqualValues(nio.SignalGroup{{"state": false}}, takeOne(t, b.ChOutState, &b.Busy))
+}
+
+func TestSwitchBlock_StateTerminal(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	b := SwitchBlock{}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Switch",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"state_expr": "{{ $state }}",
+	"group_by": "{{ $group }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	terminals := map[nio.Terminal]bool{}
+	b.EachOutput(func(terminal nio.Terminal, _ <-chan nio.SignalGroup) {
+		terminals[terminal] = true
+	})
+	assert.Equal(map[nio.Terminal]bool{"true": true, "false": true, "state": true, "evicted": true}, terminals)
+
+	put(t, &b, "setter", nio.Signal{"state": true, "group": "foo"})
+	assert.EqualValues(nio.SignalGroup{
+		{"state": true, "group": "foo"},
+	}, takeOne(t, b.ChOutState, &b.Busy))
+
+	// setting the current state again is not a change
+	put(t, &b, "setter", nio.Signal{"state": true, "group": "foo"})
+	assert.Nil(takeNone(t, b.ChOutState, &b.Busy))
+
+	put(t, &b, "setter", nio.Signal{"state": false, "group": "bar"})
+	assert.Nil(takeNone(t, b.ChOutState, &b.Busy))
+}
+
+func TestSwitchBlock_AutoReset(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-ctx.Done()
+	}()
+
+	clock := NewFakeClock(time.Now())
+	b := SwitchBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Switch",
+	"id": "0787AD0A-456D-46D5-AD47-5BFE2D8CA8BB",
+	"name": "",
+	"state_expr": "{{ $state }}",
+	"auto_reset_after": {"milliseconds": 20}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, "setter", nio.Signal{"state": true})
+	assert.EqualValues(nio.SignalGroup{{"state": true}}, takeOne(t, b.ChOutState, &b.Busy))
+
+	clock.Advance(19 * time.Millisecond)
+	takeNone(t, b.ChOutState, &b.Busy)
+
+	clock.Advance(time.Millisecond)
+	assert.EqualValues(nio.SignalGroup{{"state": false}}, takeOne(t, b.ChOutState, &b.Busy))
+
+	put(t, &b, "getter", nil)
+	assert.Len(takeOne(t, b.ChOutRight, &b.Busy), 1)
+}
+
+func TestSwitchBlock_StateOutputFull(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := NewFakeClock(time.Now())
+	b := SwitchBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Switch",
+	"state_expr": "{{ $state }}",
+	"group_by": "{{ $group }}",
+	"auto_reset_after": {"seconds": 30}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, "setter", nio.Signal{"group": "a", "state": true})
+	takeOne(t, b.ChOutState, &b.Busy)
+
+	// nothing reads the state output
+	for len(b.ChOutState) < cap(b.ChOutState) {
+		b.ChOutState <- nio.SignalGroup{}
+	}
+
+	// the reset of a waits for the output without holding up the block
+	advanced := make(chan struct{})
+	go func() {
+		clock.Advance(30 * time.Second)
+		close(advanced)
+	}()
+	for deadline := time.Now().Add(hangGuard); ; {
+		states, err := b.Command("get_state", nil)
+		assert.NoError(err)
+		if !states.(map[string]bool)["a"] {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("a was not reset")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	put(t, &b, "getter", nio.Signal{"group": "a"})
+	processed := make(chan struct{})
+	go func() {
+		b.Busy.Wait()
+		close(processed)
+	}()
+	select {
+	case <-processed:
+	case <-time.After(hangGuard):
+		t.Fatal("the getter was not processed")
+	}
+	assert.Len(takeOne(t, b.ChOutRight, &b.Busy), 1)
+
+	for i := 0; i < cap(b.ChOutState); i++ {
+		<-b.ChOutState
+	}
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"state": false, "group": "a"},
+	}, takeWithin(t, b.ChOutState))
+	<-advanced
+}
+
+func TestSwitchBlock_Commands(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := NewFakeClock(time.Now())
+	b := SwitchBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Switch",
+	"state_expr": "{{ $state }}",
+	"group_by": "{{ $group }}",
+	"auto_reset_after": {"seconds": 30}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	state, err := b.Command("get_state", map[string]interface{}{"group": "stuck"})
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{"group": "stuck", "state": false}, state)
+
+	// a group seen by a getter is listed while in the initial state
+	put(t, &b, "getter", nio.Signal{"group": "idle"})
+	assert.Len(takeOne(t, b.ChOutRight, &b.Busy), 1)
+
+	// set over the REST API, where arguments are strings
+	state, err = b.Command("set_state", map[string]interface{}{"group": "stuck", "state": "true"})
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{"group": "stuck", "state": true}, state)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"state": true, "group": "stuck"},
+	}, takeOne(t, b.ChOutState, &b.Busy))
+
+	put(t, &b, "getter", nio.Signal{"group": "stuck"})
+	assert.Len(takeOne(t, b.ChOutLeft, &b.Busy), 1)
+
+	states, err := b.Command("get_state", nil)
+	assert.NoError(err)
+	assert.Equal(map[string]bool{"idle": false, "stuck": true}, states)
+
+	// the state set by command resets like any other
+	clock.Advance(30 * time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"state": false, "group": "stuck"},
+	}, takeOne(t, b.ChOutState, &b.Busy))
+
+	// groups back in the initial state are still listed
+	states, err = b.Command("get_state", nil)
+	assert.NoError(err)
+	assert.Equal(map[string]bool{"idle": false, "stuck": false}, states)
+
+	_, err = b.Command("set_state", map[string]interface{}{"group": "stuck", "state": 1})
+	assert.EqualError(err, "set_state: state must be a boolean")
+	_, err = b.Command("set_state", map[string]interface{}{"state": true})
+	assert.EqualError(err, "set_state: group is required")
+}
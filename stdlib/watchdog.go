@@ -0,0 +1,260 @@
+package stdlib
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/mixins"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// WatchdogBlock notices groups going silent. Once a group has been seen, a
+// timeout signal is emitted for it if no other signal of the group arrives
+// within the timeout, which may be an expression evaluated against the last
+// signal of the group. A recovered signal is emitted when the group is heard
+// from again. Signals pass through the block unchanged.
+//
+// Timeout signals carry the group and when it was last seen, as last_seen;
+// recovered signals carry the group, last_seen and how long the group was
+// silent, in seconds, as silent_for.
+//
+// A group_ttl must outlast a constant timeout, or groups would expire
+// before they time out.
+type WatchdogBlock struct {
+	nio.Transformer
+	mixins.GroupByMixin
+	Config WatchdogBlockConfig
+
+	// TOutTimeout receives a signal for every group that goes silent.
+	TOutTimeout  nio.Terminal
+	ChOutTimeout chan nio.SignalGroup
+
+	// TOutRecovered receives a signal for every silent group heard from
+	// again.
+	TOutRecovered  nio.Terminal
+	ChOutRecovered chan nio.SignalGroup
+
+	// Clock times the timeouts. It defaults to RealClock.
+	Clock Clock
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	timeout hoistedDuration
+	mutex   sync.Mutex
+	groups  groupState
+	metrics blockMetrics
+	outbox  outbox
+}
+
+type WatchdogBlockConfig struct {
+	nio.BlockConfigAtom
+	Timeout *props.TimeDeltaProperty `json:"timeout" order:"0" default:"{\"seconds\": 60}"`
+	GroupStateConfig
+}
+
+// watchdogEntry is what the watchdog knows of a group.
+type watchdogEntry struct {
+	lastSeen time.Time
+	timedOut bool
+	timer    Timer
+}
+
+func (b *WatchdogBlock) Configure(config nio.RawBlockConfig) error {
+	SetTerminal(&b.TOutTimeout, "timeout")
+	SetTerminal(&b.TOutRecovered, "recovered")
+
+	b.Transformer.Configure()
+	b.ChOutTimeout = newOutputChannel()
+	b.ChOutRecovered = newOutputChannel()
+
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+	b.metrics = newBlockMetrics(b.Metrics, c.atom)
+
+	c.add("group_by", b.GroupByMixin.Configure(config, b.metrics.notify(b.Notify)))
+
+	b.timeout, err = hoistDuration(b.Config.Timeout, c.raw["timeout"], time.Minute)
+	c.add("timeout", err)
+
+	b.groups.configure(c, &b.Config.GroupStateConfig, b.Clock, &b.mutex, b.metrics)
+	// a group expiring sooner would be forgotten without timing out
+	if b.timeout.constant && b.groups.ttl > 0 && b.groups.ttl <= b.timeout.value {
+		c.addf("group_ttl", "group_ttl must be longer than the %s timeout", b.timeout.value)
+	}
+	b.groups.addGroup = b.GroupByMixin.AddGroupToSignal
+	b.groups.onEvict = func(_ mixins.Group, entry interface{}) {
+		if timer := entry.(*watchdogEntry).timer; timer != nil {
+			timer.Stop()
+		}
+	}
+	b.outbox.configure(b.metrics)
+
+	return c.err()
+}
+
+func (b *WatchdogBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	b.metrics.in(terminal, signals)
+	return b.Transformer.Enqueue(terminal, signals, 1)
+}
+
+func (b *WatchdogBlock) EachOutput(fn func(nio.Terminal, <-chan nio.SignalGroup)) {
+	b.Transformer.EachOutput(fn)
+	fn(b.TOutTimeout, b.ChOutTimeout)
+	fn(b.TOutRecovered, b.ChOutRecovered)
+	b.groups.eachOutput(fn)
+}
+
+func (b *WatchdogBlock) Start(ctx context.Context) {
+	defer b.stop()
+
+	for {
+		select {
+		case signals := <-b.ChIn:
+			b.GroupByMixin.Process(signals, b.metrics.group(b.process))
+			b.Busy.Done()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *WatchdogBlock) process(group mixins.Group, notify nio.NotifyFunc, signals nio.SignalGroup) error {
+	b.mutex.Lock()
+
+	now := clockOrReal(b.Clock).Now()
+
+	entry := &watchdogEntry{}
+	if value, ok := b.groups.get(group); ok {
+		entry = value.(*watchdogEntry)
+	}
+
+	if entry.timedOut {
+		b.notifyGroup(b.TOutRecovered, b.ChOutRecovered, group, nio.Signal{
+			"last_seen":  entry.lastSeen,
+			"silent_for": now.Sub(entry.lastSeen).Seconds(),
+		})
+	}
+
+	entry.lastSeen = now
+	entry.timedOut = false
+	if entry.timer != nil {
+		entry.timer.Stop()
+		entry.timer = nil
+	}
+
+	timeout, err := b.timeout.Invoke(signals[len(signals)-1])
+	if err != nil {
+		b.metrics.expressionError("timeout")
+	} else {
+		entry.timer = clockOrReal(b.Clock).AfterFunc(timeout, func() { b.expire(group, entry) })
+	}
+
+	b.groups.set(group, entry)
+	b.mutex.Unlock()
+	b.outbox.flush()
+
+	return notify(b.TOut, signals)
+}
+
+// expire emits the timeout signal of a group that went silent. It is added
+// to the outbox with the mutex held, so that it precedes the recovered signal
+// of the group, and sent once it is released.
+func (b *WatchdogBlock) expire(group mixins.Group, entry *watchdogEntry) {
+	defer b.outbox.flush()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// the group was heard from, evicted or the block stopped
+	if value, ok := b.groups.peek(group); !ok || value != entry || entry.timer == nil {
+		return
+	}
+	entry.timer = nil
+	entry.timedOut = true
+
+	b.notifyGroup(b.TOutTimeout, b.ChOutTimeout, group, nio.Signal{"last_seen": entry.lastSeen})
+}
+
+// notifyGroup labels a signal with its group and adds it to the outbox, to
+// be emitted on one of the watchdog's own outputs. It must be called with the
+// mutex held.
+func (b *WatchdogBlock) notifyGroup(terminal nio.Terminal, out chan nio.SignalGroup, group mixins.Group, signal nio.Signal) {
+	b.GroupByMixin.AddGroupToSignal(group, signal, true)
+	b.outbox.add(terminal, out, nio.SignalGroup{signal})
+}
+
+// stop disarms every timeout when the block stops. Signals still in the
+// outbox are sent only if their output has room.
+func (b *WatchdogBlock) stop() {
+	b.outbox.close()
+	b.outbox.flush()
+
+	b.mutex.Lock()
+	b.groups.each(func(_ mixins.Group, value interface{}) {
+		entry := value.(*watchdogEntry)
+		if entry.timer != nil {
+			entry.timer.Stop()
+			entry.timer = nil
+		}
+	})
+	b.mutex.Unlock()
+
+	b.groups.stop()
+}
+
+const watchdogVersion = "0.1.0"
+
+var Watchdog = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &WatchdogBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Namespace:  "blocks.watchdog.watchdog_block.Watchdog",
+		Version:    watchdogVersion,
+		Name:       "Watchdog",
+		Properties: groupedProperties(WatchdogBlockConfig{}, watchdogVersion),
+		Commands:   map[nio.Command]nio.CommandDefinition{},
+		BlockAttributes: nio.BlockAttributes{
+			Inputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "input",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+				{
+					Label:   "timeout",
+					Type:    "output",
+					Visible: true,
+					Order:   1,
+					ID:      "timeout",
+					Default: false,
+				},
+				{
+					Label:   "recovered",
+					Type:    "output",
+					Visible: true,
+					Order:   2,
+					ID:      "recovered",
+					Default: false,
+				},
+				groupEvictedOutput(3),
+			},
+		},
+	},
+}
@@ -0,0 +1,198 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/niolabs/gonio-blocks/stdlib"
+	"github.com/niolabs/gonio-framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchdogBlock_Basic(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := stdlib.NewFakeClock(start)
+	b := stdlib.WatchdogBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Watchdog",
+	"timeout": {"seconds": 10},
+	"group_by": "{{ $sensor }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"sensor": "adxl345", "x": 1}, nio.Signal{"sensor": "dht", "t": 20})
+	assert.Len(takeOne(t, b.ChOut, &b.Busy), 2)
+
+	clock.Advance(5 * time.Second)
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"sensor": "dht", "t": 21})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	clock.Advance(5 * time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "adxl345", "last_seen": start},
+	}, takeOne(t, b.ChOutTimeout, &b.Busy))
+	takeNone(t, b.ChOutTimeout, &b.Busy)
+
+	// a silent group times out once
+	clock.Advance(time.Minute)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "dht", "last_seen": start.Add(5 * time.Second)},
+	}, takeOne(t, b.ChOutTimeout, &b.Busy))
+	takeNone(t, b.ChOutTimeout, &b.Busy)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"sensor": "adxl345", "x": 2})
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"sensor": "adxl345", "x": 2},
+	}, takeOne(t, b.ChOut, &b.Busy))
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "adxl345", "last_seen": start, "silent_for": float64(70)},
+	}, takeOne(t, b.ChOutRecovered, &b.Busy))
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"sensor": "adxl345", "x": 3})
+	takeOne(t, b.ChOut, &b.Busy)
+	takeNone(t, b.ChOutRecovered, &b.Busy)
+}
+
+func TestWatchdogBlock_Expression(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := stdlib.NewFakeClock(start)
+	b := stdlib.WatchdogBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Watchdog",
+	"timeout": "{{ $period * 3 }}",
+	"group_by": "{{ $sensor }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"sensor": "fast", "period": 1}, nio.Signal{"sensor": "slow", "period": 10})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	clock.Advance(3 * time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "fast", "last_seen": start},
+	}, takeOne(t, b.ChOutTimeout, &b.Busy))
+
+	clock.Advance(26 * time.Second)
+	takeNone(t, b.ChOutTimeout, &b.Busy)
+
+	clock.Advance(time.Second)
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "slow", "last_seen": start},
+	}, takeOne(t, b.ChOutTimeout, &b.Busy))
+}
+
+func TestWatchdogBlock_Stop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	clock := stdlib.NewFakeClock(time.Now())
+	b := stdlib.WatchdogBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Watchdog",
+	"timeout": {"seconds": 10}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		b.Start(ctx)
+		close(stopped)
+	}()
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	cancel()
+	<-stopped
+
+	clock.Advance(time.Minute)
+	takeNone(t, b.ChOutTimeout, &b.Busy)
+}
+
+func TestWatchdogBlock_TimeoutOutputFull(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := stdlib.NewFakeClock(start)
+	b := stdlib.WatchdogBlock{Clock: clock}
+
+	if err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Watchdog",
+	"timeout": {"seconds": 10},
+	"group_by": "{{ $sensor }}"
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	go b.Start(ctx)
+
+	// nothing reads the timeout output
+	for len(b.ChOutTimeout) < cap(b.ChOutTimeout) {
+		b.ChOutTimeout <- nio.SignalGroup{}
+	}
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"sensor": "dht"})
+	takeOne(t, b.ChOut, &b.Busy)
+
+	// the timeout of dht waits for the output without holding up the block
+	advanced := make(chan struct{})
+	go func() {
+		clock.Advance(10 * time.Second)
+		close(advanced)
+	}()
+
+	put(t, &b, nio.DefaultTerminal, nio.Signal{"sensor": "adxl345"})
+	takeWithin(t, b.ChOut)
+
+	for i := 0; i < cap(b.ChOutTimeout); i++ {
+		<-b.ChOutTimeout
+	}
+	assert.EqualValues(nio.SignalGroup{
+		nio.Signal{"group": "dht", "last_seen": start},
+	}, takeWithin(t, b.ChOutTimeout))
+	<-advanced
+}
+
+func TestWatchdogBlock_ConfigErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	b := stdlib.WatchdogBlock{}
+	err := b.Configure(nio.RawBlockConfig(`{
+	"type": "Watchdog",
+	"timeout": {"seconds": 60},
+	"group_ttl": {"seconds": 30}
+}`))
+	assert.EqualError(err, "Watchdog: group_ttl: group_ttl must be longer than the 1m0s timeout")
+
+	// a timeout from an expression is only known per signal
+	b = stdlib.WatchdogBlock{}
+	err = b.Configure(nio.RawBlockConfig(`{
+	"type": "Watchdog",
+	"timeout": "{{ $timeout }}",
+	"group_ttl": {"seconds": 30}
+}`))
+	assert.NoError(err)
+}
@@ -0,0 +1,157 @@
+package stdlib
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/niolabs/gonio-framework"
+	"github.com/niolabs/gonio-framework/props"
+)
+
+// WaveformIntervalSimulatorBlock emits samples of a sine, square or sawtooth
+// wave. The signals of each batch are spaced evenly over the interval, so
+// the wave's time base is independent of how often batches are emitted.
+type WaveformIntervalSimulatorBlock struct {
+	nio.Producer
+	Config WaveformIntervalSimulatorConfig
+
+	// Clock paces the simulation. It defaults to RealClock.
+	Clock Clock
+
+	// Metrics receives the block's runtime metrics. It defaults to
+	// NopMetrics.
+	Metrics Metrics
+
+	sim     simulatorEngine
+	elapsed time.Duration
+
+	key       string
+	shape     func(phase float64) float64
+	amplitude float64
+	offset    float64
+	period    time.Duration
+	phase     float64
+}
+
+type WaveformIntervalSimulatorConfig struct {
+	nio.BlockConfigAtom
+	IntervalSimulatorConfig
+
+	Key       *props.StringProperty    `json:"attr_name" title:"Attribute Name" order:"1" default:"sim"`
+	Shape     *props.StringProperty    `json:"shape" title:"Shape" order:"2" options:"sine,square,sawtooth" default:"sine"`
+	Amplitude *props.AnyProperty       `json:"amplitude" title:"Amplitude" order:"3" type:"FloatType" default:"1"`
+	Offset    *props.AnyProperty       `json:"offset" title:"Offset" order:"6" advanced:"true" type:"FloatType" default:"0"`
+	Period    *props.TimeDeltaProperty `json:"period" title:"Period" order:"4" default:"{\"seconds\": 10}"`
+	Phase     *props.AnyProperty       `json:"phase" title:"Phase (degrees)" order:"5" advanced:"true" type:"FloatType" default:"0"`
+}
+
+// waveformShapes map a phase in [0, 1) to a value in [-1, 1].
+var waveformShapes = map[string]func(float64) float64{
+	"sine": func(phase float64) float64 {
+		return math.Sin(2 * math.Pi * phase)
+	},
+	"square": func(phase float64) float64 {
+		if phase < 0.5 {
+			return 1
+		}
+		return -1
+	},
+	"sawtooth": func(phase float64) float64 {
+		return 2*phase - 1
+	},
+}
+
+func (b *WaveformIntervalSimulatorBlock) Configure(config nio.RawBlockConfig) error {
+	b.Producer.Configure()
+
+	c, err := decodeConfig(config, &b.Config)
+	if err != nil {
+		return err
+	}
+
+	b.sim.configure(c, &b.Config.IntervalSimulatorConfig, b.Clock, b.Metrics)
+
+	c.add("attr_name", b.Config.Key.AssignToDefault(&b.key, nil, "sim"))
+
+	var shape string
+	if c.add("shape", b.Config.Shape.AssignToDefault(&shape, nil, "sine")) {
+		if b.shape = waveformShapes[shape]; b.shape == nil {
+			c.addf("shape", "invalid shape `%s'", shape)
+		}
+	}
+
+	c.add("amplitude", assignFloatDefault(b.Config.Amplitude, &b.amplitude, 1))
+	c.add("offset", assignFloatDefault(b.Config.Offset, &b.offset, 0))
+	// phase is given in degrees
+	c.add("phase", assignFloatDefault(b.Config.Phase, &b.phase, 0))
+
+	if c.add("period", b.Config.Period.AssignToDefault(&b.period, nil, 10*time.Second)) && b.period <= 0 {
+		c.addf("period", "period must be positive")
+	}
+
+	b.elapsed = 0
+	b.sim.onReset = func() { b.elapsed = 0 }
+
+	return c.err()
+}
+
+func (b *WaveformIntervalSimulatorBlock) Enqueue(terminal nio.Terminal, signals nio.SignalGroup) error {
+	if b.sim.enqueue(terminal, signals) {
+		return nil
+	}
+	return b.NoEnqueue(terminal)
+}
+
+func (b *WaveformIntervalSimulatorBlock) Command(command nio.Command, args map[string]interface{}) (interface{}, error) {
+	return b.sim.command(command, args)
+}
+
+func (b *WaveformIntervalSimulatorBlock) Start(ctx context.Context) {
+	b.sim.run(ctx, b.TOut, b.ChOut, b.generate)
+}
+
+func (b *WaveformIntervalSimulatorBlock) generate(num int64) (nio.SignalGroup, bool) {
+	spacing := b.sim.interval()
+	if b.sim.count > 1 {
+		spacing /= time.Duration(b.sim.count)
+	}
+
+	signals := make(nio.SignalGroup, num)
+	for i := range signals {
+		_, phase := math.Modf(float64(b.elapsed)/float64(b.period) + b.phase/360)
+		if phase < 0 {
+			phase++
+		}
+
+		signals[i] = nio.Signal{b.key: b.offset + b.amplitude*b.shape(phase)}
+		b.elapsed += spacing
+	}
+	return signals, false
+}
+
+const waveformIntervalSimulatorVersion = "0.1.0"
+
+var WaveformIntervalSimulator = nio.BlockTypeEntry{
+	Create: func() nio.Block { return &WaveformIntervalSimulatorBlock{} },
+	Definition: nio.BlockTypeDefinition{
+		Version: waveformIntervalSimulatorVersion,
+		BlockAttributes: nio.BlockAttributes{
+			Outputs: []nio.TerminalDefinition{
+				{
+					Label:   "default",
+					Type:    "output",
+					Visible: true,
+					Order:   0,
+					ID:      "__default_terminal_value",
+					Default: true,
+				},
+			},
+			Inputs: simulatorInputs,
+		},
+		Namespace:  "goblocks.simulator.blocks.WaveformIntervalSimulator",
+		Properties: definitionProperties(WaveformIntervalSimulatorConfig{}, waveformIntervalSimulatorVersion),
+		Commands:   simulatorCommands,
+		Name:       "WaveformIntervalSimulator",
+	},
+}